@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/OmarDardery/solve-the-x-backend/models"
+	"gorm.io/gorm"
+)
+
+// LeaderboardRefresh recomputes the leaderboard's materialized view every 10 minutes
+func LeaderboardRefresh() Job {
+	return Job{
+		Name: "leaderboard_refresh",
+		Next: Every(10 * time.Minute),
+		Run: func(ctx context.Context, db *gorm.DB) error {
+			return models.RefreshLeaderboard(db)
+		},
+	}
+}
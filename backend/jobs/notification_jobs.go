@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/OmarDardery/solve-the-x-backend/models"
+	"gorm.io/gorm"
+)
+
+// NotificationDigests sends daily and weekly digests. Users pick their own time and timezone,
+// so it checks every 15 minutes for digests whose slot has passed.
+func NotificationDigests() Job {
+	return Job{
+		Name: "notification_digests",
+		Next: Every(15 * time.Minute),
+		Run: func(ctx context.Context, db *gorm.DB) error {
+			return models.SendNotificationDigests(db, time.Now())
+		},
+	}
+}
+
+// NotificationRetention purges read notifications older than maxAge every night
+func NotificationRetention(maxAge time.Duration) Job {
+	return Job{
+		Name: "notification_retention",
+		Next: Every(24 * time.Hour),
+		Run: func(ctx context.Context, db *gorm.DB) error {
+			purged, err := models.PurgeReadNotifications(db, maxAge)
+			if purged > 0 {
+				log.Printf("jobs: purged %d read notifications", purged)
+			}
+			return err
+		},
+	}
+}
+
+// OutboxRetention purges sent emails older than maxAge every night
+func OutboxRetention(maxAge time.Duration) Job {
+	return Job{
+		Name: "outbox_retention",
+		Next: Every(24 * time.Hour),
+		Run: func(ctx context.Context, db *gorm.DB) error {
+			purged, err := models.PurgeSentOutboxEmails(db, maxAge)
+			if purged > 0 {
+				log.Printf("jobs: purged %d sent emails", purged)
+			}
+			return err
+		},
+	}
+}
@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/OmarDardery/solve-the-x-backend/models"
+	"gorm.io/gorm"
+)
+
+// MissingReportReminders reminds students every Friday to submit the current week's report
+func MissingReportReminders() Job {
+	return Job{
+		Name: "missing_report_reminders",
+		Next: Weekly(time.Friday, 12, 0),
+		Run: func(ctx context.Context, db *gorm.DB) error {
+			year, week := time.Now().UTC().ISOWeek()
+			return models.SendMissingReportReminders(db, year, week)
+		},
+	}
+}
+
+// OverdueReportDigest tells professors every Monday which students did not report the previous week
+func OverdueReportDigest() Job {
+	return Job{
+		Name: "overdue_report_digest",
+		Next: Weekly(time.Monday, 9, 0),
+		Run: func(ctx context.Context, db *gorm.DB) error {
+			year, week := time.Now().UTC().AddDate(0, 0, -7).ISOWeek()
+			return models.SendOverdueReportDigests(db, year, week)
+		},
+	}
+}
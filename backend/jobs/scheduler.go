@@ -0,0 +1,176 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/OmarDardery/solve-the-x-backend/models"
+	"gorm.io/gorm"
+)
+
+// leaderLockKey is the Postgres advisory lock held by the replica that runs jobs
+const leaderLockKey int64 = 839_201_746 // arbitrary, but identical on every replica
+
+// Job is a unit of background work run on a recurring schedule
+type Job struct {
+	Name string
+	// Next returns the first run time strictly after t
+	Next func(t time.Time) time.Time
+	Run  func(ctx context.Context, db *gorm.DB) error
+}
+
+// Scheduler runs registered jobs on exactly one replica. Replicas compete for a session-level
+// advisory lock; the holder is the leader and claims due jobs from the scheduled_jobs table.
+type Scheduler struct {
+	db           *gorm.DB
+	jobs         []Job
+	instance     string
+	pollInterval time.Duration
+	leader       *sql.Conn
+}
+
+// NewScheduler creates a scheduler that checks for due jobs every pollInterval
+func NewScheduler(db *gorm.DB, pollInterval time.Duration) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		db:           db,
+		instance:     fmt.Sprintf("%s-%d", host, os.Getpid()),
+		pollInterval: pollInterval,
+	}
+}
+
+// Register adds a job. Must be called before Start.
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start registers the jobs' schedules and runs the scheduling loop in the background until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) error {
+	now := time.Now()
+	for _, job := range s.jobs {
+		if err := models.EnsureScheduledJob(s.db, job.Name, job.Next(now)); err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		defer s.resign()
+		for {
+			if s.elect(ctx) {
+				s.runDue(ctx)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// elect reports whether this replica is the leader, trying to become it if not.
+// Leadership lasts as long as the connection holding the lock; if it drops, Postgres releases the lock.
+func (s *Scheduler) elect(ctx context.Context) bool {
+	if s.leader != nil {
+		if err := s.leader.PingContext(ctx); err == nil {
+			return true
+		}
+		log.Printf("jobs: lost leader connection")
+		s.resign()
+	}
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		log.Printf("jobs: %v", err)
+		return false
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.Printf("jobs: %v", err)
+		return false
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockKey).Scan(&acquired); err != nil || !acquired {
+		if err != nil {
+			log.Printf("jobs: leader election: %v", err)
+		}
+		conn.Close()
+		return false
+	}
+
+	log.Printf("jobs: %s is now the job leader", s.instance)
+	s.leader = conn
+	return true
+}
+
+func (s *Scheduler) resign() {
+	if s.leader == nil {
+		return
+	}
+	s.leader.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", leaderLockKey)
+	s.leader.Close()
+	s.leader = nil
+}
+
+// runDue claims and runs every job whose time has come. Claiming is atomic, so even two
+// leaders during a failover cannot run the same occurrence twice.
+func (s *Scheduler) runDue(ctx context.Context) {
+	for _, job := range s.jobs {
+		now := time.Now()
+		claimed, err := models.ClaimScheduledJob(s.db, job.Name, s.instance, now, job.Next(now))
+		if err != nil {
+			log.Printf("jobs: claim %s: %v", job.Name, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		log.Printf("jobs: running %s", job.Name)
+		runErr := s.run(ctx, job)
+		if runErr != nil {
+			log.Printf("jobs: %s failed: %v", job.Name, runErr)
+		}
+		if err := models.FinishScheduledJob(s.db, job.Name, runErr); err != nil {
+			log.Printf("jobs: record %s: %v", job.Name, err)
+		}
+	}
+}
+
+// run executes a job, turning a panic into an error so one bad job cannot stop the scheduler
+func (s *Scheduler) run(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx, s.db.WithContext(ctx))
+}
+
+// Weekly returns a schedule firing every week on the given weekday and time (UTC)
+func Weekly(weekday time.Weekday, hour, minute int) func(time.Time) time.Time {
+	return func(t time.Time) time.Time {
+		t = t.UTC()
+		next := time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, time.UTC)
+		next = next.AddDate(0, 0, (int(weekday)-int(next.Weekday())+7)%7)
+		if !next.After(t) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	}
+}
+
+// Every returns a schedule firing at fixed intervals aligned to the clock, e.g. :00, :15, :30 and :45
+func Every(interval time.Duration) func(time.Time) time.Time {
+	return func(t time.Time) time.Time {
+		return t.UTC().Truncate(interval).Add(interval)
+	}
+}
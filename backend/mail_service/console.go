@@ -0,0 +1,17 @@
+package mail_service
+
+import "log"
+
+// ConsoleMailer logs each message instead of sending it
+type ConsoleMailer struct {
+	Logger *log.Logger
+}
+
+func (m *ConsoleMailer) Provider() string {
+	return "console"
+}
+
+func (m *ConsoleMailer) Send(msg Message) error {
+	m.Logger.Printf("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
@@ -0,0 +1,32 @@
+package mail_service
+
+import "time"
+
+// DigestItem is one notification in a digest
+type DigestItem struct {
+	Title     string
+	Message   string
+	CreatedAt time.Time
+}
+
+// DigestGroup collects the notifications of one type
+type DigestGroup struct {
+	Title string
+	Items []DigestItem
+}
+
+// Digest summarizes a recipient's unread notifications for a period
+type Digest struct {
+	Frequency string // daily or weekly
+	Since     time.Time
+	Groups    []DigestGroup
+}
+
+// Count returns the number of notifications in the digest
+func (d Digest) Count() int {
+	count := 0
+	for _, group := range d.Groups {
+		count += len(group.Items)
+	}
+	return count
+}
@@ -0,0 +1,41 @@
+package mail_service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// FileMailer writes each message as an .eml file, which mail clients can open, for local development
+type FileMailer struct {
+	Dir  string
+	From Sender
+}
+
+// NewFileMailer creates dir if needed and returns a mailer writing into it
+func NewFileMailer(dir string, from Sender) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{Dir: dir, From: from}, nil
+}
+
+func (m *FileMailer) Provider() string {
+	return "file"
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func (m *FileMailer) Send(msg Message) error {
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	file, err := os.Create(filepath.Join(m.Dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = mimeMessage(m.From, msg).WriteTo(file)
+	return err
+}
@@ -0,0 +1,71 @@
+package mail_service
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Message is a rendered email ready for delivery
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string // extra headers, e.g. List-Unsubscribe
+}
+
+// Mailer delivers email through one backend
+type Mailer interface {
+	Send(msg Message) error
+	// Provider names the backend, e.g. for metrics and per-provider rate limits
+	Provider() string
+}
+
+// Sender is the From address of outgoing mail
+type Sender struct {
+	Name  string
+	Email string
+}
+
+func (s Sender) String() string {
+	return fmt.Sprintf("%s <%s>", s.Name, s.Email)
+}
+
+// NewMailerFromEnv builds the mailer selected by MAIL_SERVICE:
+// sendgrid (the default), smtp (or local), console, file or memory.
+// Unrecognized values fall back to SendGrid, as they always have.
+func NewMailerFromEnv() (Mailer, error) {
+	from := Sender{Name: "Solve-The-X", Email: os.Getenv("SENDER_EMAIL")}
+
+	switch service := os.Getenv("MAIL_SERVICE"); service {
+	case "", "sendgrid":
+		return &SendGridMailer{APIKey: os.Getenv("SENDGRID_API_KEY"), From: from}, nil
+	case "smtp", "local":
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMTP port: %w", err)
+		}
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}, nil
+	case "console":
+		return &ConsoleMailer{Logger: log.Default()}, nil
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "tmp/mail"
+		}
+		return NewFileMailer(dir, from)
+	case "memory":
+		return &MemoryMailer{}, nil
+	default:
+		log.Printf("mail: unknown MAIL_SERVICE %q, using sendgrid", service)
+		return &SendGridMailer{APIKey: os.Getenv("SENDGRID_API_KEY"), From: from}, nil
+	}
+}
@@ -0,0 +1,95 @@
+package mail_service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMemoryMailerRecordsRenderedTemplate(t *testing.T) {
+	tests := []struct {
+		locale  string
+		subject string
+	}{
+		{"en", "Verify your SolveX account"},
+		{"ar", "تأكيد حسابك على SolveX"},
+		{"fr", "Verify your SolveX account"}, // untranslated locales fall back to English
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			email, err := Templates.Render("verification", tt.locale, "Alex", VerificationData{Code: "482913"}, nil)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			mailer := &MemoryMailer{}
+			if err := mailer.Send(Message{To: "alex@example.com", Subject: email.Subject, Text: email.Text, HTML: email.HTML}); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+
+			sent := mailer.MessagesTo("alex@example.com")
+			if len(sent) != 1 {
+				t.Fatalf("got %d messages, want 1", len(sent))
+			}
+			msg := sent[0]
+			if msg.Subject != tt.subject {
+				t.Errorf("subject = %q, want %q", msg.Subject, tt.subject)
+			}
+			if !strings.Contains(msg.Text, "482913") || !strings.Contains(msg.HTML, "482913") {
+				t.Errorf("code missing from body:\ntext: %s\nhtml: %s", msg.Text, msg.HTML)
+			}
+		})
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, err := Templates.Render("no_such_template", DefaultLocale, "", nil, nil); err == nil {
+		t.Fatal("expected an error for an unknown template")
+	}
+}
+
+func TestMemoryMailerErr(t *testing.T) {
+	sendErr := errors.New("provider down")
+	mailer := &MemoryMailer{Err: sendErr}
+	if err := mailer.Send(Message{To: "alex@example.com"}); !errors.Is(err, sendErr) {
+		t.Fatalf("Send error = %v, want %v", err, sendErr)
+	}
+	if n := len(mailer.Messages()); n != 0 {
+		t.Errorf("recorded %d messages after a failed send, want 0", n)
+	}
+
+	mailer.Err = nil
+	mailer.Send(Message{To: "alex@example.com"})
+	mailer.Send(Message{To: "sam@example.com"})
+	if n := len(mailer.MessagesTo("sam@example.com")); n != 1 {
+		t.Errorf("got %d messages to sam, want 1", n)
+	}
+	mailer.Reset()
+	if n := len(mailer.Messages()); n != 0 {
+		t.Errorf("recorded %d messages after Reset, want 0", n)
+	}
+}
+
+func TestNewMailerFromEnv(t *testing.T) {
+	tests := []struct {
+		service  string
+		provider string
+	}{
+		{"", "sendgrid"},
+		{"sendgrid", "sendgrid"},
+		{"memory", "memory"},
+		{"console", "console"},
+		{"mailgun", "sendgrid"}, // unknown services fall back to SendGrid
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			t.Setenv("MAIL_SERVICE", tt.service)
+			mailer, err := NewMailerFromEnv()
+			if err != nil {
+				t.Fatalf("NewMailerFromEnv: %v", err)
+			}
+			if got := mailer.Provider(); got != tt.provider {
+				t.Errorf("provider = %q, want %q", got, tt.provider)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package mail_service
+
+import "sync"
+
+// MemoryMailer records messages instead of sending them, so tests can assert on what was sent
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+	// Err, if set, is returned by Send without recording the message
+	Err error
+}
+
+func (m *MemoryMailer) Provider() string {
+	return "memory"
+}
+
+func (m *MemoryMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns every recorded message, oldest first
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// MessagesTo returns the recorded messages sent to one address
+func (m *MemoryMailer) MessagesTo(to string) []Message {
+	var sent []Message
+	for _, msg := range m.Messages() {
+		if msg.To == to {
+			sent = append(sent, msg)
+		}
+	}
+	return sent
+}
+
+// Reset forgets all recorded messages
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
@@ -0,0 +1,39 @@
+package mail_service
+
+import (
+	"fmt"
+
+	"github.com/sendgrid/sendgrid-go"
+	"github.com/sendgrid/sendgrid-go/helpers/mail"
+)
+
+// SendGridMailer sends email through the SendGrid API
+type SendGridMailer struct {
+	APIKey string
+	From   Sender
+}
+
+func (m *SendGridMailer) Provider() string {
+	return "sendgrid"
+}
+
+func (m *SendGridMailer) Send(msg Message) error {
+	from := mail.NewEmail(m.From.Name, m.From.Email)
+	to := mail.NewEmail("", msg.To)
+
+	message := mail.NewSingleEmail(from, msg.Subject, to, msg.Text, msg.HTML)
+	for name, value := range msg.Headers {
+		message.SetHeader(name, value)
+	}
+	client := sendgrid.NewSendClient(m.APIKey)
+	response, err := client.Send(message)
+	if err != nil {
+		return fmt.Errorf("sendgrid error: %w", err)
+	}
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("sendgrid error: %d - %s", response.StatusCode, response.Body)
+	}
+
+	return nil
+}
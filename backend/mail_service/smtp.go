@@ -0,0 +1,48 @@
+package mail_service
+
+import (
+	"fmt"
+
+	"gopkg.in/gomail.v2"
+)
+
+// SMTPMailer sends email through an SMTP server
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     Sender
+}
+
+func (m *SMTPMailer) Provider() string {
+	return "smtp"
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	dialer := gomail.NewDialer(m.Host, m.Port, m.Username, m.Password)
+
+	// For local development without auth
+	if m.Username == "" && m.Password == "" {
+		dialer.Auth = nil
+	}
+
+	if err := dialer.DialAndSend(mimeMessage(m.From, msg)); err != nil {
+		return fmt.Errorf("smtp error: %w", err)
+	}
+	return nil
+}
+
+// mimeMessage builds a multipart message with plain text and HTML alternatives
+func mimeMessage(from Sender, msg Message) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("From", from.String())
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	for name, value := range msg.Headers {
+		m.SetHeader(name, value)
+	}
+	m.SetBody("text/plain", msg.Text)
+	m.AddAlternative("text/html", msg.HTML)
+	return m
+}
@@ -0,0 +1,262 @@
+package mail_service
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// DefaultLocale is used when a recipient's language has no template variant
+const DefaultLocale = "en"
+
+// Locales lists the languages email templates are translated into
+var Locales = []string{"en", "ar"}
+
+// Email is a rendered message ready to send
+type Email struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// Data for each template
+type (
+	VerificationData struct {
+		Code string
+	}
+	PasswordResetData struct {
+		Link      string
+		ExpiresIn string
+	}
+	ApplicationStatusData struct {
+		OpportunityName string
+		Status          string
+	}
+	NewReportData struct {
+		StudentName string
+		Year        int
+		Week        int
+	}
+	EventReminderData struct {
+		EventTitle string
+		When       string
+		Location   string
+	}
+	NotificationData struct {
+		Title   string
+		Message string
+	}
+)
+
+// sampleData is rendered by the admin preview. password_reset and event_reminder are translated
+// but nothing sends them yet: there is no password reset flow and no event reminder job.
+var sampleData = map[string]any{
+	"verification":       VerificationData{Code: "482913"},
+	"password_reset":     PasswordResetData{Link: "https://solvex.dardery.work/reset-password?token=sample", ExpiresIn: "1 hour"},
+	"application_status": ApplicationStatusData{OpportunityName: "Machine learning research assistant", Status: "accepted"},
+	"new_report":         NewReportData{StudentName: "Mariam Hassan", Year: 2026, Week: 42},
+	"event_reminder":     EventReminderData{EventTitle: "Intro to robotics workshop", When: "tomorrow at 16:00", Location: "Hall B, main campus"},
+	"notification":       NotificationData{Title: "🤝 Added as Collaborator", Message: "You were added to 'Smart irrigation' as editor"},
+	"digest": Digest{
+		Frequency: "daily",
+		Since:     time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC),
+		Groups: []DigestGroup{
+			{Title: "Weekly reports", Items: []DigestItem{
+				{Title: "📝 Weekly Report Submitted", Message: "Mariam Hassan submitted a weekly report"},
+				{Title: "📝 Weekly Report Submitted", Message: "Youssef Adel submitted a weekly report"},
+			}},
+			{Title: "New applications", Items: []DigestItem{
+				{Title: "📥 New Application", Message: "Omar Khaled applied to 'Smart irrigation'"},
+			}},
+		},
+	},
+}
+
+// layoutStrings are the localized pieces of the shared layout
+type layoutStrings struct {
+	Greeting    string // format taking the recipient's name
+	Tagline     string
+	Footer      string
+	Unsubscribe string
+}
+
+var layouts = map[string]struct {
+	Dir     string
+	Align   string
+	Strings layoutStrings
+}{
+	"en": {Dir: "ltr", Align: "left", Strings: layoutStrings{
+		Greeting:    "Hi %s,",
+		Tagline:     "The infinite search for knowledge",
+		Footer:      "You are receiving this email because you have a SolveX account.",
+		Unsubscribe: "Unsubscribe from these emails",
+	}},
+	"ar": {Dir: "rtl", Align: "right", Strings: layoutStrings{
+		Greeting:    "مرحبًا %s،",
+		Tagline:     "البحث اللانهائي عن المعرفة",
+		Footer:      "تصلك هذه الرسالة لأن لديك حسابًا على SolveX.",
+		Unsubscribe: "إلغاء الاشتراك في هذه الرسائل",
+	}},
+}
+
+// layoutData is what the shared layout renders; Content is the template's own data
+type layoutData struct {
+	Locale    string
+	Dir       string
+	Align     string
+	Subject   string
+	Recipient string
+	LogoURL   string
+	Strings   layoutStrings
+	Content   any
+	// UnsubscribeURL is linked in the footer; empty for emails that cannot be opted out of, like verification codes
+	UnsubscribeURL string
+}
+
+// TemplateRegistry holds every email template, parsed once, in each locale
+type TemplateRegistry struct {
+	html  map[string]*htmltemplate.Template // keyed by locale/name
+	text  map[string]*texttemplate.Template
+	names []string
+}
+
+// Templates is the registry of the embedded templates
+var Templates = mustLoadTemplates()
+
+func mustLoadTemplates() *TemplateRegistry {
+	registry, err := NewTemplateRegistry()
+	if err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// NewTemplateRegistry parses the embedded templates. Every template must exist in the default locale;
+// other locales may translate any subset.
+func NewTemplateRegistry() (*TemplateRegistry, error) {
+	registry := &TemplateRegistry{
+		html: map[string]*htmltemplate.Template{},
+		text: map[string]*texttemplate.Template{},
+	}
+
+	for _, locale := range Locales {
+		files, err := templateFS.ReadDir("templates/" + locale)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			name, ok := strings.CutSuffix(file.Name(), ".html")
+			if !ok {
+				continue
+			}
+			key := locale + "/" + name
+
+			html, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+key+".html")
+			if err != nil {
+				return nil, err
+			}
+			text, err := texttemplate.ParseFS(templateFS, "templates/layout.txt", "templates/"+key+".txt")
+			if err != nil {
+				return nil, err
+			}
+			if text.Lookup("subject") == nil {
+				return nil, fmt.Errorf("email template %s has no subject", key)
+			}
+			registry.html[key] = html
+			registry.text[key] = text
+			if locale == DefaultLocale {
+				registry.names = append(registry.names, name)
+			}
+		}
+	}
+	sort.Strings(registry.names)
+	return registry, nil
+}
+
+// Names lists the available templates
+func (r *TemplateRegistry) Names() []string {
+	return r.names
+}
+
+// Render renders a template in the given locale, falling back to the default locale.
+// recipient is the name used in the greeting; leave it empty to skip the greeting.
+// unsubscribe, if not nil, adds an unsubscribe link to the footer.
+func (r *TemplateRegistry) Render(name, locale, recipient string, data any, unsubscribe *Unsubscribe) (*Email, error) {
+	if _, ok := r.html[locale+"/"+name]; !ok {
+		locale = DefaultLocale
+	}
+	key := locale + "/" + name
+	html, text := r.html[key], r.text[key]
+	if html == nil {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+
+	layout := layouts[locale]
+	page := layoutData{
+		Locale:    locale,
+		Dir:       layout.Dir,
+		Align:     layout.Align,
+		Subject:   strings.TrimSpace(subject.String()),
+		Recipient: recipient,
+		LogoURL:   appURL() + "/WhiteLogo.png",
+		Strings:   layout.Strings,
+		Content:   data,
+	}
+	if unsubscribe != nil {
+		page.UnsubscribeURL = UnsubscribePageURL(*unsubscribe)
+	}
+
+	var htmlBody, textBody bytes.Buffer
+	if err := html.ExecuteTemplate(&htmlBody, "layout", page); err != nil {
+		return nil, err
+	}
+	if err := text.ExecuteTemplate(&textBody, "layout", page); err != nil {
+		return nil, err
+	}
+	return &Email{Subject: page.Subject, Text: textBody.String(), HTML: htmlBody.String()}, nil
+}
+
+// Preview renders a template with sample data
+func (r *TemplateRegistry) Preview(name, locale string) (*Email, error) {
+	data, ok := sampleData[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	return r.Render(name, locale, "Alex", data, nil)
+}
+
+// MatchLocale picks the best supported locale from an Accept-Language header or locale code
+func MatchLocale(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		for _, locale := range Locales {
+			if tag == locale || strings.HasPrefix(tag, locale+"-") {
+				return locale
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// appURL is APP_URL, the public address of the web app, used for links and images in emails.
+// One-click unsubscribes go to the backend instead, at API_URL (see apiURL).
+func appURL() string {
+	if url := os.Getenv("APP_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return "https://solvex.dardery.work"
+}
@@ -0,0 +1,120 @@
+package mail_service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidUnsubscribeToken is returned for tokens that were altered or not issued by us
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// Unsubscribe identifies whose emails of which category to stop
+type Unsubscribe struct {
+	RecipientID   uint
+	RecipientRole string
+	Category      string // a notification event type, or "digest"
+}
+
+// unsubscribeSecret signs unsubscribe tokens. Without UNSUBSCRIBE_SECRET it derives a key from the JWT
+// secret with a purpose prefix, so deployments need no new config yet a token never doubles as a JWT signature.
+func unsubscribeSecret() []byte {
+	if secret := os.Getenv("UNSUBSCRIBE_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		return nil
+	}
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte("solvex unsubscribe v1"))
+	return mac.Sum(nil)
+}
+
+// UnsubscribeToken signs a recipient and category. Tokens do not expire, since unsubscribe links in
+// old emails must keep working.
+func UnsubscribeToken(u Unsubscribe) string {
+	payload := fmt.Sprintf("%s:%d:%s", u.RecipientRole, u.RecipientID, u.Category)
+	mac := hmac.New(sha256.New, unsubscribeSecret())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ParseUnsubscribeToken checks a token's signature and returns what it unsubscribes from
+func ParseUnsubscribeToken(token string) (Unsubscribe, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return Unsubscribe{}, ErrInvalidUnsubscribeToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Unsubscribe{}, ErrInvalidUnsubscribeToken
+	}
+	given, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return Unsubscribe{}, ErrInvalidUnsubscribeToken
+	}
+	secret := unsubscribeSecret()
+	if len(secret) == 0 {
+		return Unsubscribe{}, ErrInvalidUnsubscribeToken // an empty key would accept forged tokens
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	if !hmac.Equal(given, mac.Sum(nil)) {
+		return Unsubscribe{}, ErrInvalidUnsubscribeToken
+	}
+
+	parts := strings.SplitN(string(payload), ":", 3)
+	if len(parts) != 3 {
+		return Unsubscribe{}, ErrInvalidUnsubscribeToken
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return Unsubscribe{}, ErrInvalidUnsubscribeToken
+	}
+	return Unsubscribe{RecipientID: uint(id), RecipientRole: parts[0], Category: parts[2]}, nil
+}
+
+// UnsubscribePageURL is the web page linked from an email's footer, where the recipient confirms
+// before anything changes
+func UnsubscribePageURL(u Unsubscribe) string {
+	return appURL() + "/unsubscribe?token=" + url.QueryEscape(UnsubscribeToken(u))
+}
+
+// UnsubscribeHeaders are the List-Unsubscribe headers (RFC 2369, with one-click from RFC 8058)
+// pointing at the public unsubscribe endpoint. Without API_URL there is nowhere to post, so there are none.
+func UnsubscribeHeaders(u Unsubscribe) map[string]string {
+	base := apiURL()
+	if base == "" {
+		return nil
+	}
+	link := base + "/public/unsubscribe?token=" + url.QueryEscape(UnsubscribeToken(u))
+	return map[string]string{
+		"List-Unsubscribe":      "<" + link + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// CheckUnsubscribeConfig fails for mailers that reach real inboxes when API_URL is unset. Mail clients
+// post one-click unsubscribes to it directly, and APP_URL is the web app, which would accept the post
+// and change nothing.
+func CheckUnsubscribeConfig(mailer Mailer) error {
+	switch mailer.Provider() {
+	case "sendgrid", "smtp":
+		if apiURL() == "" {
+			return errors.New("API_URL must be set to the backend's public address")
+		}
+	}
+	return nil
+}
+
+// apiURL is API_URL, the public address of this backend
+func apiURL() string {
+	return strings.TrimSuffix(os.Getenv("API_URL"), "/")
+}
@@ -0,0 +1,33 @@
+package mail_service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnsubscribeHeadersNeedAPIURL(t *testing.T) {
+	u := Unsubscribe{RecipientID: 7, RecipientRole: "student", Category: "reports"}
+
+	t.Setenv("API_URL", "")
+	t.Setenv("APP_URL", "https://app.example.com")
+	if headers := UnsubscribeHeaders(u); headers != nil {
+		t.Errorf("headers without API_URL = %v, want none", headers)
+	}
+	for _, mailer := range []Mailer{&SendGridMailer{}, &SMTPMailer{}} {
+		if err := CheckUnsubscribeConfig(mailer); err == nil {
+			t.Errorf("%s without API_URL: expected an error", mailer.Provider())
+		}
+	}
+	if err := CheckUnsubscribeConfig(&MemoryMailer{}); err != nil {
+		t.Errorf("memory without API_URL: %v", err)
+	}
+
+	t.Setenv("API_URL", "https://api.example.com/")
+	if err := CheckUnsubscribeConfig(&SendGridMailer{}); err != nil {
+		t.Errorf("sendgrid with API_URL: %v", err)
+	}
+	link := UnsubscribeHeaders(u)["List-Unsubscribe"]
+	if !strings.HasPrefix(link, "<https://api.example.com/public/unsubscribe?token=") {
+		t.Errorf("List-Unsubscribe = %q, want a link to the API", link)
+	}
+}
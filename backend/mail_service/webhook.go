@@ -0,0 +1,185 @@
+package mail_service
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of delivery outcomes reported by providers
+const (
+	HardBounce = "hard_bounce" // the address does not exist or permanently rejects mail
+	SoftBounce = "soft_bounce" // temporary rejection, e.g. a full mailbox
+	Complaint  = "complaint"   // the recipient marked our email as spam
+	Delivered  = "delivered"   // the recipient's server accepted the message
+)
+
+// DeliveryEvent is a bounce, complaint or successful delivery for one address
+type DeliveryEvent struct {
+	Email  string
+	Kind   string
+	Reason string
+	At     time.Time
+}
+
+// ErrInvalidSignature is returned when a webhook request was not signed by the provider
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// webhookTolerance bounds how old a signed generic webhook request may be, to stop replays
+const webhookTolerance = 5 * time.Minute
+
+// VerifySendGridSignature checks a SendGrid Signed Event Webhook request. publicKey is the base64
+// verification key from the SendGrid settings; signature and timestamp come from the
+// X-Twilio-Email-Event-Webhook-Signature and X-Twilio-Email-Event-Webhook-Timestamp headers.
+// Requests signed more than webhookTolerance from now are rejected, so captured requests cannot be replayed.
+func VerifySendGridSignature(publicKey, signature, timestamp string, body []byte, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return ErrInvalidSignature
+	}
+
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return err
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return err
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return errors.New("sendgrid webhook key is not an ECDSA key")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(key, digest[:], sig) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParseSendGridEvents extracts deliveries, bounces and complaints from a SendGrid Event Webhook payload,
+// ignoring open, click and other events
+func ParseSendGridEvents(body []byte) ([]DeliveryEvent, error) {
+	var payload []struct {
+		Email     string `json:"email"`
+		Event     string `json:"event"`
+		Type      string `json:"type"`
+		Reason    string `json:"reason"`
+		Timestamp int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	events := []DeliveryEvent{}
+	for _, e := range payload {
+		event := DeliveryEvent{Email: e.Email, Reason: e.Reason, At: time.Now()}
+		if e.Timestamp > 0 {
+			event.At = time.Unix(e.Timestamp, 0)
+		}
+		switch {
+		case e.Event == "spamreport":
+			event.Kind = Complaint
+		case e.Event == "bounce" && e.Type == "blocked":
+			event.Kind = SoftBounce
+		case e.Event == "bounce":
+			event.Kind = HardBounce
+		case e.Event == "dropped" && e.Reason == "Bounced Address":
+			event.Kind = HardBounce
+		case e.Event == "dropped" && e.Reason == "Spam Reporting Address":
+			event.Kind = Complaint
+		case e.Event == "delivered":
+			event.Kind = Delivered
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// VerifyWebhookSignature checks a generic webhook request signed with a shared secret:
+// the X-Webhook-Signature header is the hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>"
+func VerifyWebhookSignature(secret, signature, timestamp string, body []byte, now time.Time) error {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > webhookTolerance || age < -webhookTolerance {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	given, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !hmac.Equal(given, expected) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// ParseGenericEvents reads one event or a list of events in the generic format:
+// {"email": "...", "type": "bounce" | "complaint" | "delivered", "bounce_type": "hard" | "soft", "reason": "...", "timestamp": 1700000000}
+func ParseGenericEvents(body []byte) ([]DeliveryEvent, error) {
+	type genericEvent struct {
+		Email      string `json:"email"`
+		Type       string `json:"type"`
+		BounceType string `json:"bounce_type"`
+		Reason     string `json:"reason"`
+		Timestamp  int64  `json:"timestamp"`
+	}
+	var payload []genericEvent
+	if trimmed := strings.TrimSpace(string(body)); strings.HasPrefix(trimmed, "{") {
+		var single genericEvent
+		if err := json.Unmarshal(body, &single); err != nil {
+			return nil, err
+		}
+		payload = append(payload, single)
+	} else if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	events := make([]DeliveryEvent, 0, len(payload))
+	for _, e := range payload {
+		if e.Email == "" {
+			return nil, errors.New("email is required")
+		}
+		event := DeliveryEvent{Email: e.Email, Reason: e.Reason, At: time.Now()}
+		if e.Timestamp > 0 {
+			event.At = time.Unix(e.Timestamp, 0)
+		}
+		switch {
+		case e.Type == "complaint":
+			event.Kind = Complaint
+		case e.Type == "bounce" && e.BounceType == "soft":
+			event.Kind = SoftBounce
+		case e.Type == "bounce":
+			event.Kind = HardBounce
+		case e.Type == "delivered":
+			event.Kind = Delivered
+		default:
+			return nil, errors.New("type must be bounce, complaint or delivered")
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
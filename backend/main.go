@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/OmarDardery/solve-the-x-backend/database"
+	"github.com/OmarDardery/solve-the-x-backend/jobs"
 	"github.com/OmarDardery/solve-the-x-backend/mail_service"
 	"github.com/OmarDardery/solve-the-x-backend/middleware"
 	"github.com/OmarDardery/solve-the-x-backend/models"
+	"github.com/OmarDardery/solve-the-x-backend/outbox"
+	"github.com/OmarDardery/solve-the-x-backend/realtime"
 	"github.com/OmarDardery/solve-the-x-backend/routes"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -28,20 +35,118 @@ func main() {
 	if err := db.AutoMigrate(
 		&models.Professor{},
 		&models.Tag{},
+		&models.TagAlias{},
 		&models.Student{},
 		&models.Coins{},
+		&models.CoinTransaction{},
+		&models.CoinRule{},
 		&models.Opportunity{},
+		&models.OpportunityCollaborator{},
 		&models.Application{},
 		&models.WeeklyReport{},
+		&models.ReportAttachment{},
+		&models.ReportComment{},
 		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.DigestSetting{},
+		&models.DigestDelivery{},
+		&models.OutboxEmail{},
+		&models.MailRateSlot{},
+		&models.EmailSuppression{},
 		&models.Organization{},
 		&models.Event{},
+		&models.EventAttendance{},
+		&models.Bookmark{},
+		&models.SavedSearch{},
+		&models.ScheduledJob{},
+		&models.StreamTicket{},
+		&models.Reward{},
+		&models.Redemption{},
 	); err != nil {
 		panic("failed to migrate database")
 	}
 
-	// Initialize mail service
-	mailman := mail_service.NewMailman()
+	if err := models.MigrateNotificationRecipientRoles(db); err != nil {
+		panic("failed to migrate notification recipient roles")
+	}
+	if err := models.IndexNotifications(db); err != nil {
+		panic("failed to index notifications")
+	}
+
+	if err := models.SyncAdmins(db, strings.Split(os.Getenv("ADMIN_EMAILS"), ",")); err != nil {
+		panic("failed to sync admins")
+	}
+
+	// Normalize tags created before slugs existed
+	if err := models.BackfillTagSlugs(db); err != nil {
+		panic("failed to backfill tag slugs")
+	}
+	models.EnableTagSearch(db)
+	if err := models.BackfillPublishedAt(db); err != nil {
+		panic("failed to backfill opportunity publish dates")
+	}
+	if err := models.BackfillOpportunityOwners(db); err != nil {
+		panic("failed to backfill opportunity owners")
+	}
+	if err := models.BackfillCoinLedger(db); err != nil {
+		panic("failed to backfill coin ledger")
+	}
+	if err := models.SeedCoinRules(db); err != nil {
+		panic("failed to seed coin rules")
+	}
+	if err := models.BackfillReportWeeks(db); err != nil {
+		panic("failed to backfill report weeks")
+	}
+	if err := models.BackfillAcceptedAt(db); err != nil {
+		panic("failed to backfill application acceptance dates")
+	}
+	// Old events only have a free-form date; read it in EVENT_TIMEZONE, the zone they were posted in
+	eventZone, err := time.LoadLocation(os.Getenv("EVENT_TIMEZONE"))
+	if err != nil {
+		panic("invalid EVENT_TIMEZONE")
+	}
+	if err := models.MigrateEventDates(db, eventZone); err != nil {
+		panic("failed to migrate event dates")
+	}
+	if err := models.EnableLeaderboard(db); err != nil {
+		panic("failed to set up leaderboard")
+	}
+
+	// Initialize mail service. Emails are queued in the outbox and sent by these workers on every replica.
+	mailer, err := mail_service.NewMailerFromEnv()
+	if err != nil {
+		panic("failed to configure mail service: " + err.Error())
+	}
+	if err := mail_service.CheckUnsubscribeConfig(mailer); err != nil {
+		panic("failed to configure mail service: " + err.Error())
+	}
+	outbox.NewPool(db, mailer, 4).Start(context.Background())
+
+	// Background jobs run on whichever replica wins leader election
+	scheduler := jobs.NewScheduler(db, time.Minute)
+	scheduler.Register(jobs.MissingReportReminders())
+	scheduler.Register(jobs.OverdueReportDigest())
+	scheduler.Register(jobs.NotificationDigests())
+	scheduler.Register(jobs.LeaderboardRefresh())
+	// Read notifications are kept for NOTIFICATION_RETENTION_DAYS (default 90)
+	retentionDays, err := strconv.Atoi(os.Getenv("NOTIFICATION_RETENTION_DAYS"))
+	if err != nil || retentionDays < 1 {
+		retentionDays = 90
+	}
+	scheduler.Register(jobs.NotificationRetention(time.Duration(retentionDays) * 24 * time.Hour))
+	// Sent emails are kept for OUTBOX_RETENTION_DAYS (default 7); their bodies can contain verification codes
+	outboxRetentionDays, err := strconv.Atoi(os.Getenv("OUTBOX_RETENTION_DAYS"))
+	if err != nil || outboxRetentionDays < 1 {
+		outboxRetentionDays = 7
+	}
+	scheduler.Register(jobs.OutboxRetention(time.Duration(outboxRetentionDays) * 24 * time.Hour))
+	if err := scheduler.Start(context.Background()); err != nil {
+		panic("failed to start job scheduler")
+	}
+
+	// Push notifications to connected clients; every replica listens for notifications created anywhere
+	hub := realtime.NewHub()
+	go models.StreamNotifications(context.Background(), db, os.Getenv("DATABASE_URL"), hub)
 
 	// Initialize Gin router
 	server := gin.Default()
@@ -54,7 +159,7 @@ func main() {
 	server.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://127.0.0.1:3000", "https://solvex.dardery.work"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Last-Event-ID"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 	}))
@@ -81,9 +186,9 @@ func main() {
 		c.File(filePath)
 	})
 	auth := server.Group("/auth")
-	auth.POST("/sign-up/:role", routes.SignUpHandler(db, &verificationCodes, mailman))
+	auth.POST("/sign-up/:role", routes.SignUpHandler(db, &verificationCodes))
 	auth.POST("/sign-in/:role", routes.SignInHandler(db))
-	auth.POST("/send-code", routes.SendCodeHandler(db, &verificationCodes, mailman))
+	auth.POST("/send-code", routes.SendCodeHandler(db, &verificationCodes))
 
 	// Public routes (no authentication required)
 	public := server.Group("/public")
@@ -104,7 +209,7 @@ func main() {
 			"user": user,
 		})
 	})
-	routes.RegisterCRUDRoutes(protected, db)
+	routes.RegisterCRUDRoutes(protected, db, hub)
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8000"
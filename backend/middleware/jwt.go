@@ -3,6 +3,7 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/OmarDardery/solve-the-x-backend/jwt_service"
 	"github.com/OmarDardery/solve-the-x-backend/models"
@@ -13,38 +14,14 @@ import (
 // JWTMiddleware verifies JWT tokens and attaches user info (role + struct) to the Gin context
 func JWTMiddleware(db *gorm.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		const bearerPrefix = "Bearer "
-		authHeader := c.GetHeader("Authorization")
-		if len(authHeader) <= len(bearerPrefix) || authHeader[:len(bearerPrefix)] != bearerPrefix {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing Authorization header"})
-			c.Abort()
-			return
-		}
-
-		tokenString := authHeader[len(bearerPrefix):]
-		claims, err := jwt_service.ParseJWT(tokenString)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
-
-		role, ok := claims["role"].(string)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token payload"})
-			c.Abort()
-			return
-		}
-
-		idFloat, ok := claims["user_id"].(float64)
+		role, id, ok := authenticate(c, db)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token payload"})
 			c.Abort()
 			return
 		}
-		id := uint(idFloat)
 
 		var user interface{}
+		var err error
 		switch role {
 		case "student":
 			user, err = models.GetStudentByID(db, id)
@@ -66,6 +43,47 @@ func JWTMiddleware(db *gorm.DB) gin.HandlerFunc {
 
 		c.Set("role", role)
 		c.Set("user", user)
+		c.Set("is_admin", models.IsAdmin(user))
 		c.Next()
 	}
 }
+
+// authenticate returns the role and ID of the caller from the bearer token. EventSource cannot set
+// headers, so the notification stream takes a single-use ?ticket= instead; the JWT never goes in a URL.
+func authenticate(c *gin.Context, db *gorm.DB) (string, uint, bool) {
+	if ticket := c.Query("ticket"); ticket != "" && strings.HasSuffix(c.Request.URL.Path, "/stream") {
+		id, role, err := models.RedeemStreamTicket(db, ticket)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired stream ticket"})
+			return "", 0, false
+		}
+		return role, id, true
+	}
+
+	const bearerPrefix = "Bearer "
+	authHeader := c.GetHeader("Authorization")
+	if len(authHeader) <= len(bearerPrefix) || authHeader[:len(bearerPrefix)] != bearerPrefix {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing Authorization header"})
+		return "", 0, false
+	}
+
+	tokenString := authHeader[len(bearerPrefix):]
+	claims, err := jwt_service.ParseJWT(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return "", 0, false
+	}
+
+	role, ok := claims["role"].(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token payload"})
+		return "", 0, false
+	}
+
+	idFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token payload"})
+		return "", 0, false
+	}
+	return role, uint(idFloat), true
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SyncAdmins makes exactly the accounts with the given emails admins. Emails are verified at sign-up
+// and cannot be changed afterwards, so an address in the list can only belong to its owner.
+func SyncAdmins(db *gorm.DB, emails []string) error {
+	normalized := []string{}
+	for _, email := range emails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			normalized = append(normalized, email)
+		}
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&Student{}, &Professor{}, &Organization{}} {
+			isAdmin := gorm.Expr("FALSE")
+			if len(normalized) > 0 {
+				isAdmin = gorm.Expr("LOWER(email) IN ?", normalized)
+			}
+			if err := tx.Model(model).Where("TRUE").Update("is_admin", isAdmin).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// IsAdmin reports whether a logged-in user (a *Student, *Professor or *Organization) is an admin
+func IsAdmin(user interface{}) bool {
+	switch u := user.(type) {
+	case *Student:
+		return u.IsAdmin
+	case *Professor:
+		return u.IsAdmin
+	case *Organization:
+		return u.IsAdmin
+	}
+	return false
+}
@@ -1,6 +1,13 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 type Application struct {
 	gorm.Model
@@ -11,6 +18,7 @@ type Application struct {
 	Message       string       `json:"message" gorm:"type:text"`
 	ResumeLink    string       `json:"resume_link"`
 	Status        string       `json:"status" gorm:"type:TEXT CHECK(status IN ('pending','accepted','rejected'));not null;default:'pending'"`
+	AcceptedAt    *time.Time   `json:"accepted_at" gorm:"index"` // when the status last became accepted; nil unless accepted
 }
 
 const (
@@ -19,6 +27,9 @@ const (
 	StatusRejected = "rejected"
 )
 
+// ErrOpportunityNotOpen is returned when applying to a draft or closed opportunity
+var ErrOpportunityNotOpen = errors.New("opportunity is not accepting applications")
+
 func (s Student) CreateApplication(db *gorm.DB, opportunityID uint, message, resumeLink string) error {
 	application := Application{
 		StudentID:     s.ID,
@@ -27,7 +38,23 @@ func (s Student) CreateApplication(db *gorm.DB, opportunityID uint, message, res
 		ResumeLink:    resumeLink,
 		Status:        StatusPending,
 	}
-	return db.Create(&application).Error
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Lock the opportunity so it cannot close between the check and the insert
+		var opportunity Opportunity
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&opportunity, opportunityID).Error; err != nil {
+			return err
+		}
+		if opportunity.Status != OpportunityOpen {
+			return ErrOpportunityNotOpen
+		}
+
+		if err := tx.Create(&application).Error; err != nil {
+			return err
+		}
+
+		// Let the people running the opportunity know
+		return NotifyNewApplication(tx, opportunityID, opportunity.Name, s.FirstName+" "+s.LastName)
+	})
 }
 
 func (s Student) DeleteApplication(db *gorm.DB, opportunityID uint) error {
@@ -55,6 +82,7 @@ func GetApplicationsByStudentID(db *gorm.DB, studentID uint) ([]Application, err
 		Preload("Student").
 		Preload("Opportunity").
 		Preload("Opportunity.Professor").
+		Preload("Opportunity.Organization").
 		Where("student_id = ?", studentID).
 		Order("created_at DESC").
 		Find(&applications).
@@ -62,15 +90,31 @@ func GetApplicationsByStudentID(db *gorm.DB, studentID uint) ([]Application, err
 	return applications, err
 }
 
-// GetApplicationsByProfessorOpportunities returns all applications for opportunities created by a professor
+// GetApplicationsByProfessorOpportunities returns all applications for opportunities a professor collaborates on
 func GetApplicationsByProfessorOpportunities(db *gorm.DB, professorID uint) ([]Application, error) {
 	var applications []Application
 	err := db.
 		Preload("Student").
 		Preload("Opportunity").
 		Preload("Opportunity.Professor").
+		Preload("Opportunity.Organization").
+		Joins("JOIN opportunity_collaborators ON opportunity_collaborators.opportunity_id = applications.opportunity_id AND opportunity_collaborators.deleted_at IS NULL").
+		Where("opportunity_collaborators.professor_id = ?", professorID).
+		Order("applications.created_at DESC").
+		Find(&applications).
+		Error
+	return applications, err
+}
+
+// GetApplicationsByOrganizationOpportunities returns all applications for opportunities posted by an organization
+func GetApplicationsByOrganizationOpportunities(db *gorm.DB, organizationID uint) ([]Application, error) {
+	var applications []Application
+	err := db.
+		Preload("Student").
+		Preload("Opportunity").
+		Preload("Opportunity.Organization").
 		Joins("JOIN opportunities ON opportunities.id = applications.opportunity_id").
-		Where("opportunities.professor_id = ?", professorID).
+		Where("opportunities.organization_id = ?", organizationID).
 		Order("applications.created_at DESC").
 		Find(&applications).
 		Error
@@ -84,6 +128,7 @@ func GetApplicationByID(db *gorm.DB, id uint) (*Application, error) {
 		Preload("Student").
 		Preload("Opportunity").
 		Preload("Opportunity.Professor").
+		Preload("Opportunity.Organization").
 		First(&application, id).
 		Error
 	return &application, err
@@ -103,18 +148,48 @@ func UpdateApplicationStatus(db *gorm.DB, id uint, status string) (*Application,
 
 	oldStatus := application.Status
 	application.Status = status
-	if err := db.Save(application).Error; err != nil {
-		return nil, err
+	if status != StatusAccepted {
+		application.AcceptedAt = nil
+	} else if oldStatus != StatusAccepted || application.AcceptedAt == nil {
+		now := time.Now()
+		application.AcceptedAt = &now
 	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(application).Error; err != nil {
+			return err
+		}
 
-	// Notify student if status changed to accepted or rejected
-	if oldStatus != status && (status == StatusAccepted || status == StatusRejected) {
-		opportunityName := "this opportunity"
-		if application.Opportunity != nil {
-			opportunityName = application.Opportunity.Name
+		// Notify student if status changed to accepted or rejected
+		if oldStatus != status && (status == StatusAccepted || status == StatusRejected) {
+			opportunityName := "this opportunity"
+			if application.Opportunity != nil {
+				opportunityName = application.Opportunity.Name
+			}
+			if err := NotifyApplicationStatusChange(tx, application.StudentID, opportunityName, status); err != nil {
+				return err
+			}
 		}
-		NotifyApplicationStatusChange(db, application.StudentID, opportunityName, status)
+
+		// Reward the student once per accepted application. A failed award rolls back on its own and
+		// should not block the decision.
+		if status == StatusAccepted {
+			if _, err := AwardCoinsForRule(tx, RuleApplicationAccepted, application.StudentID, "application", application.ID, "system", nil); err != nil && !errors.Is(err, ErrAlreadyAwarded) {
+				log.Printf("coin award for application %d: %v", application.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return application, nil
 }
+
+// BackfillAcceptedAt estimates when applications accepted before AcceptedAt existed were accepted
+func BackfillAcceptedAt(db *gorm.DB) error {
+	return db.Model(&Application{}).
+		Where("status = ? AND accepted_at IS NULL", StatusAccepted).
+		Update("accepted_at", gorm.Expr("updated_at")).
+		Error
+}
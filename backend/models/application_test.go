@@ -0,0 +1,47 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCreateApplicationRequiresOpenOpportunity(t *testing.T) {
+	db := openTestDB(t,
+		&Professor{}, &Organization{}, &Tag{}, &TagAlias{}, &Student{}, &Opportunity{}, &OpportunityCollaborator{}, &Application{},
+		&Notification{}, &NotificationPreference{}, &DigestSetting{}, &OutboxEmail{}, &EmailSuppression{},
+	)
+
+	org := Organization{Name: "Application Test Org", Email: "application-test-org@example.com"}
+	if err := db.Create(&org).Error; err != nil {
+		t.Fatalf("create organization: %v", err)
+	}
+	student := Student{FirstName: "Alex", LastName: "Doe", Email: "application-test-student@example.com"}
+	if err := db.Create(&student).Error; err != nil {
+		t.Fatalf("create student: %v", err)
+	}
+
+	for _, status := range []string{OpportunityDraft, OpportunityClosed, OpportunityOpen} {
+		t.Run(status, func(t *testing.T) {
+			op, err := CreateOpportunity(db, "organization", org.ID, "Application Test "+status, "", "", "", "project", status, nil)
+			if err != nil {
+				t.Fatalf("CreateOpportunity: %v", err)
+			}
+
+			err = student.CreateApplication(db, op.ID, "", "")
+			if status == OpportunityOpen {
+				if err != nil {
+					t.Fatalf("CreateApplication: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrOpportunityNotOpen) {
+				t.Fatalf("CreateApplication error = %v, want ErrOpportunityNotOpen", err)
+			}
+			var count int64
+			db.Model(&Application{}).Where("opportunity_id = ?", op.ID).Count(&count)
+			if count != 0 {
+				t.Errorf("stored %d applications, want 0", count)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Bookmark is an opportunity a student saved for later
+type Bookmark struct {
+	gorm.Model
+	StudentID     uint         `json:"student_id" gorm:"not null;uniqueIndex:idx_bookmark_student_opportunity"`
+	Student       *Student     `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:StudentID;references:ID"`
+	OpportunityID uint         `json:"opportunity_id" gorm:"not null;uniqueIndex:idx_bookmark_student_opportunity"`
+	Opportunity   *Opportunity `json:"opportunity,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:OpportunityID;references:ID"`
+}
+
+// CreateBookmark saves an opportunity for a student (bookmarking twice is a no-op)
+func CreateBookmark(db *gorm.DB, studentID, opportunityID uint) (*Bookmark, error) {
+	op, err := GetOpportunityByID(db, opportunityID)
+	if err != nil {
+		return nil, err
+	}
+	if op.Status == OpportunityDraft {
+		return nil, errors.New("opportunity not found")
+	}
+
+	bookmark := Bookmark{StudentID: studentID, OpportunityID: opportunityID}
+	if err := db.Where(&bookmark).FirstOrCreate(&bookmark).Error; err != nil {
+		return nil, err
+	}
+
+	bookmark.Opportunity = op
+	return &bookmark, nil
+}
+
+// GetBookmarksByStudentID returns a student's bookmarks, newest first, skipping deleted opportunities
+func GetBookmarksByStudentID(db *gorm.DB, studentID uint) ([]Bookmark, error) {
+	var bookmarks []Bookmark
+	err := db.
+		Preload("Opportunity").
+		Preload("Opportunity.Professor").
+		Preload("Opportunity.Organization").
+		Preload("Opportunity.RequirementTags").
+		Joins("JOIN opportunities ON opportunities.id = bookmarks.opportunity_id AND opportunities.deleted_at IS NULL").
+		Where("bookmarks.student_id = ?", studentID).
+		Order("bookmarks.created_at DESC").
+		Find(&bookmarks).
+		Error
+	return bookmarks, err
+}
+
+// DeleteBookmark removes a student's bookmark for an opportunity
+func DeleteBookmark(db *gorm.DB, studentID, opportunityID uint) error {
+	// Hard delete so the opportunity can be bookmarked again without hitting the unique index
+	result := db.Unscoped().Where("student_id = ? AND opportunity_id = ?", studentID, opportunityID).Delete(&Bookmark{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("bookmark not found")
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CoinRule configures how many coins an activity earns. Admins can change amounts or disable rules.
+type CoinRule struct {
+	Event       string    `json:"event" gorm:"primarykey"`
+	Amount      int       `json:"amount" gorm:"not null"`
+	Enabled     bool      `json:"enabled" gorm:"not null;default:true"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+const (
+	RuleApplicationAccepted = "application_accepted"
+	RuleReportApproved      = "report_approved"
+	RuleEventAttended       = "event_attended"
+	// For discretionary awards the amount is the most a single professor or organization may grant per 30 days.
+	// Event attendance awards count against the organization's limit too.
+	RuleDiscretionaryLimit = "discretionary_limit"
+)
+
+// cappedReasons are the ledger reasons counted against a granter's discretionary limit
+var cappedReasons = []string{"discretionary", RuleEventAttended}
+
+// ErrAlreadyAwarded is returned when an idempotent award was already granted
+var ErrAlreadyAwarded = errors.New("coins already awarded")
+
+var defaultCoinRules = []CoinRule{
+	{Event: RuleApplicationAccepted, Amount: 50, Enabled: true, Description: "Application accepted by a professor or organization"},
+	{Event: RuleReportApproved, Amount: 10, Enabled: true, Description: "Weekly report approved by the supervising professor"},
+	{Event: RuleEventAttended, Amount: 20, Enabled: true, Description: "Attendance confirmed by the organizing organization"},
+	{Event: RuleDiscretionaryLimit, Amount: 100, Enabled: true, Description: "Maximum discretionary and attendance coins one professor or organization can grant per 30 days"},
+}
+
+// SeedCoinRules inserts the default rules without overwriting values an admin has changed
+func SeedCoinRules(db *gorm.DB) error {
+	for _, rule := range defaultCoinRules {
+		if err := db.Where(CoinRule{Event: rule.Event}).FirstOrCreate(&rule).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetCoinRules lists all reward rules
+func GetCoinRules(db *gorm.DB) ([]CoinRule, error) {
+	var rules []CoinRule
+	err := db.Order("event").Find(&rules).Error
+	return rules, err
+}
+
+// GetCoinRule returns the rule for an event
+func GetCoinRule(db *gorm.DB, event string) (*CoinRule, error) {
+	var rule CoinRule
+	if err := db.First(&rule, "event = ?", event).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("coin rule not found")
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateCoinRule changes a rule's amount and whether it is active. Nil values keep the current setting.
+func UpdateCoinRule(db *gorm.DB, event string, amount *int, enabled *bool) (*CoinRule, error) {
+	if amount != nil && *amount < 0 {
+		return nil, errors.New("amount cannot be negative")
+	}
+	rule, err := GetCoinRule(db, event)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if amount != nil {
+		updates["amount"] = *amount
+	}
+	if enabled != nil {
+		updates["enabled"] = *enabled
+	}
+	if len(updates) == 0 {
+		return rule, nil
+	}
+	if err := db.Model(rule).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return GetCoinRule(db, event)
+}
+
+// AwardCoinsForRule grants the rule's amount once per (rule, reference): repeated calls for the
+// same reference return ErrAlreadyAwarded. Disabled or zero-amount rules award nothing.
+func AwardCoinsForRule(db *gorm.DB, event string, studentID uint, referenceType string, referenceID uint, actorRole string, actorID *uint) (*CoinTransaction, error) {
+	key := fmt.Sprintf("%s:%s:%d:%d", event, referenceType, referenceID, studentID)
+	return awardCoinsOnce(db, event, studentID, referenceType, referenceID, actorRole, actorID, key)
+}
+
+// awardCoinsOnce grants the rule's amount unless an award with the same idempotency key exists.
+// Everything runs in its own (nested) transaction, so a failure leaves a caller's transaction usable.
+func awardCoinsOnce(db *gorm.DB, event string, studentID uint, referenceType string, referenceID uint, actorRole string, actorID *uint, key string) (*CoinTransaction, error) {
+	var transaction *CoinTransaction
+	err := db.Transaction(func(tx *gorm.DB) error {
+		rule, err := GetCoinRule(tx, event)
+		if err != nil {
+			return err
+		}
+		if !rule.Enabled || rule.Amount <= 0 {
+			return nil
+		}
+
+		transaction, err = IncrementCoins(tx, studentID, rule.Amount, CoinEntry{
+			Reason:         event,
+			ActorRole:      actorRole,
+			ActorID:        actorID,
+			ReferenceType:  referenceType,
+			ReferenceID:    &referenceID,
+			IdempotencyKey: key,
+		})
+		if err != nil {
+			return err
+		}
+		return NotifyCoinsAwarded(tx, studentID, rule.Amount, rule.Description)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// GrantDiscretionaryCoins lets a professor or organization reward a student, capped per granter over 30 days
+func GrantDiscretionaryCoins(db *gorm.DB, granterRole string, granterID, studentID uint, amount int, note string) (*CoinTransaction, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	if _, err := GetStudentByID(db, studentID); err != nil {
+		return nil, err
+	}
+
+	limit, err := GetCoinRule(db, RuleDiscretionaryLimit)
+	if err != nil {
+		return nil, err
+	}
+	if !limit.Enabled {
+		return nil, errors.New("discretionary awards are disabled")
+	}
+
+	var transaction *CoinTransaction
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := checkGrantLimit(tx, granterRole, granterID, amount, limit.Amount); err != nil {
+			return err
+		}
+
+		var err error
+		transaction, err = IncrementCoins(tx, studentID, amount, CoinEntry{
+			Reason:    "discretionary",
+			Note:      note,
+			ActorRole: granterRole,
+			ActorID:   &granterID,
+		})
+		if err != nil {
+			return err
+		}
+		return NotifyCoinsAwarded(tx, studentID, amount, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// ErrGrantLimitReached is returned when an award would exceed the granter's 30-day limit
+var ErrGrantLimitReached = errors.New("award exceeds your limit")
+
+// checkGrantLimit fails if granting amount would take a professor or organization over their 30-day limit.
+// It locks the granter for the rest of tx, so concurrent grants cannot exceed the cap together.
+func checkGrantLimit(tx *gorm.DB, granterRole string, granterID uint, amount, limit int) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf("discretionary:%s:%d", granterRole, granterID)).Error; err != nil {
+		return err
+	}
+
+	var granted int64
+	if err := tx.Model(&CoinTransaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("reason IN ? AND actor_role = ? AND actor_id = ? AND created_at >= ?", cappedReasons, granterRole, granterID, time.Now().AddDate(0, 0, -30)).
+		Scan(&granted).Error; err != nil {
+		return err
+	}
+	if granted+int64(amount) > int64(limit) {
+		return fmt.Errorf("%w: %d of %d coins left for the last 30 days", ErrGrantLimitReached, max(int64(limit)-granted, 0), limit)
+	}
+	return nil
+}
+
+// AwardAttendanceCoins grants the attendance reward once per event and student. The organization pays it
+// out of its discretionary limit, so creating events cannot mint unlimited coins.
+func AwardAttendanceCoins(db *gorm.DB, event *Event, studentID uint) (*CoinTransaction, error) {
+	rule, err := GetCoinRule(db, RuleEventAttended)
+	if err != nil {
+		return nil, err
+	}
+	if !rule.Enabled || rule.Amount <= 0 {
+		return nil, nil
+	}
+	limit, err := GetCoinRule(db, RuleDiscretionaryLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var transaction *CoinTransaction
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := checkGrantLimit(tx, "organization", event.OrganizationID, rule.Amount, limit.Amount); err != nil {
+			return err
+		}
+
+		var err error
+		transaction, err = IncrementCoins(tx, studentID, rule.Amount, CoinEntry{
+			Reason:         RuleEventAttended,
+			ActorRole:      "organization",
+			ActorID:        &event.OrganizationID,
+			ReferenceType:  "event",
+			ReferenceID:    &event.ID,
+			IdempotencyKey: fmt.Sprintf("%s:%s:%d:%d", RuleEventAttended, "event", event.ID, studentID),
+		})
+		if err != nil {
+			return err
+		}
+		return NotifyCoinsAwarded(tx, studentID, rule.Amount, rule.Description)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -13,6 +14,36 @@ type Coins struct {
 	Student   *Student `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:StudentID;references:ID"`
 }
 
+// CoinTransaction is an append-only ledger entry recording why a student's balance changed.
+// Rows are never updated or deleted; the balance in Coins always equals the sum of a student's entries.
+type CoinTransaction struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+	StudentID     uint      `json:"student_id" gorm:"not null;index"`
+	Student       *Student  `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:StudentID;references:ID"`
+	Amount        int       `json:"amount" gorm:"not null"` // positive for credits, negative for debits
+	BalanceAfter  int       `json:"balance_after" gorm:"not null"`
+	Reason        string    `json:"reason" gorm:"not null"`
+	Note          string    `json:"note"`
+	ActorRole     string    `json:"actor_role"` // who caused the change: student, professor, organization or system
+	ActorID       *uint     `json:"actor_id"`
+	ReferenceType string    `json:"reference_type"` // related entity, e.g. "application" or "report"
+	ReferenceID   *uint     `json:"reference_id"`
+	// Set for awards that must happen at most once (e.g. one reward per accepted application)
+	IdempotencyKey *string `json:"-" gorm:"uniqueIndex"`
+}
+
+// CoinEntry describes why a balance changes; it becomes the ledger row
+type CoinEntry struct {
+	Reason         string
+	Note           string
+	ActorRole      string
+	ActorID        *uint
+	ReferenceType  string
+	ReferenceID    *uint
+	IdempotencyKey string
+}
+
 // CreateCoins creates a new Coins record for a student
 func CreateCoins(db *gorm.DB, studentID uint) error {
 	coins := Coins{
@@ -34,36 +65,101 @@ func GetCoinsByStudentID(db *gorm.DB, studentID uint) (*Coins, error) {
 	return &coins, nil
 }
 
-// IncrementCoins increases a student's coins by a specified amount
-func IncrementCoins(db *gorm.DB, studentID uint, amount int) error {
-	coins, err := GetCoinsByStudentID(db, studentID)
-	if err != nil {
-		return err
+// IncrementCoins credits a student's balance and records the ledger entry
+func IncrementCoins(db *gorm.DB, studentID uint, amount int, entry CoinEntry) (*CoinTransaction, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
 	}
+	return applyCoinChange(db, studentID, amount, entry)
+}
 
-	coins.Amount += amount
-	if err := db.Save(coins).Error; err != nil {
-		return err
+// DecrementCoins debits a student's balance (never below zero) and records the ledger entry
+func DecrementCoins(db *gorm.DB, studentID uint, amount int, entry CoinEntry) (*CoinTransaction, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
 	}
-
-	return nil
+	return applyCoinChange(db, studentID, -amount, entry)
 }
 
-// DecrementCoins decreases a student's coins by a specified amount (with validation)
-func DecrementCoins(db *gorm.DB, studentID uint, amount int) error {
-	coins, err := GetCoinsByStudentID(db, studentID)
-	if err != nil {
-		return err
+// applyCoinChange updates the balance with a single conditional UPDATE so concurrent requests
+// cannot lose updates or overdraw, then appends the ledger row in the same transaction
+func applyCoinChange(db *gorm.DB, studentID uint, delta int, entry CoinEntry) (*CoinTransaction, error) {
+	if entry.Reason == "" {
+		return nil, errors.New("a reason is required")
 	}
 
-	if coins.Amount < amount {
-		return errors.New("insufficient coins")
+	var transaction *CoinTransaction
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// The unique index on idempotency_key backs this check up under concurrency
+		var idempotencyKey *string
+		if entry.IdempotencyKey != "" {
+			idempotencyKey = &entry.IdempotencyKey
+			var existing int64
+			if err := tx.Model(&CoinTransaction{}).Where("idempotency_key = ?", entry.IdempotencyKey).Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				return ErrAlreadyAwarded
+			}
+		}
+
+		var balances []int
+		if err := tx.Raw(
+			"UPDATE coins SET amount = amount + ?, updated_at = ? WHERE student_id = ? AND deleted_at IS NULL AND amount + ? >= 0 RETURNING amount",
+			delta, time.Now(), studentID, delta,
+		).Scan(&balances).Error; err != nil {
+			return err
+		}
+		if len(balances) == 0 {
+			if _, err := GetCoinsByStudentID(tx, studentID); err != nil {
+				return err
+			}
+			return errors.New("insufficient coins")
+		}
+
+		transaction = &CoinTransaction{
+			StudentID:      studentID,
+			Amount:         delta,
+			BalanceAfter:   balances[0],
+			Reason:         entry.Reason,
+			Note:           entry.Note,
+			ActorRole:      entry.ActorRole,
+			ActorID:        entry.ActorID,
+			ReferenceType:  entry.ReferenceType,
+			ReferenceID:    entry.ReferenceID,
+			IdempotencyKey: idempotencyKey,
+		}
+		return tx.Create(transaction).Error
+	})
+	if err != nil {
+		// A concurrent award with the same key committed between our check and insert
+		if entry.IdempotencyKey != "" && isUniqueViolation(err) {
+			return nil, ErrAlreadyAwarded
+		}
+		return nil, err
 	}
+	return transaction, nil
+}
 
-	coins.Amount -= amount
-	if err := db.Save(coins).Error; err != nil {
-		return err
+// GetCoinTransactionsByStudentID returns a student's ledger, newest first.
+// Pass the last seen ID as beforeID to fetch the next page (0 starts from the newest).
+func GetCoinTransactionsByStudentID(db *gorm.DB, studentID, beforeID uint, limit int) ([]CoinTransaction, error) {
+	transactions := []CoinTransaction{}
+	query := db.Where("student_id = ?", studentID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
 	}
+	err := query.Order("id DESC").Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
 
-	return nil
+// BackfillCoinLedger records an opening balance for students whose coins predate the ledger
+func BackfillCoinLedger(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO coin_transactions (created_at, student_id, amount, balance_after, reason, actor_role)
+		SELECT NOW(), c.student_id, c.amount, c.amount, 'opening_balance', 'system'
+		FROM coins c
+		WHERE c.deleted_at IS NULL AND c.amount <> 0
+		AND NOT EXISTS (SELECT 1 FROM coin_transactions t WHERE t.student_id = c.student_id)`,
+	).Error
 }
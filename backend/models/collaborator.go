@@ -0,0 +1,189 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// OpportunityCollaborator grants a professor access to an opportunity they co-supervise
+type OpportunityCollaborator struct {
+	gorm.Model
+	OpportunityID uint         `json:"opportunity_id" gorm:"not null;uniqueIndex:idx_collaborator_opportunity_professor"`
+	Opportunity   *Opportunity `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:OpportunityID;references:ID"`
+	ProfessorID   uint         `json:"professor_id" gorm:"not null;uniqueIndex:idx_collaborator_opportunity_professor;index"`
+	Professor     *Professor   `json:"professor,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ProfessorID;references:ID"`
+	Role          string       `json:"role" gorm:"type:TEXT CHECK(role IN ('owner','editor','viewer'));not null;default:'viewer'"`
+}
+
+const (
+	CollaboratorOwner  = "owner"  // edit, delete, manage collaborators
+	CollaboratorEditor = "editor" // edit the opportunity and decide on applications
+	CollaboratorViewer = "viewer" // read applications
+)
+
+var collaboratorRank = map[string]int{
+	CollaboratorViewer: 1,
+	CollaboratorEditor: 2,
+	CollaboratorOwner:  3,
+}
+
+// RoleAtLeast reports whether role grants at least the permissions of minRole
+func RoleAtLeast(role, minRole string) bool {
+	return collaboratorRank[role] >= collaboratorRank[minRole] && collaboratorRank[role] > 0
+}
+
+// GetCollaboratorRole returns the professor's role on an opportunity, or "" if they are not a collaborator
+func GetCollaboratorRole(db *gorm.DB, opportunityID, professorID uint) (string, error) {
+	var collaborator OpportunityCollaborator
+	err := db.Where("opportunity_id = ? AND professor_id = ?", opportunityID, professorID).First(&collaborator).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return collaborator.Role, nil
+}
+
+// GetCollaborators lists the professors working on an opportunity
+func GetCollaborators(db *gorm.DB, opportunityID uint) ([]OpportunityCollaborator, error) {
+	var collaborators []OpportunityCollaborator
+	err := db.
+		Preload("Professor").
+		Where("opportunity_id = ?", opportunityID).
+		Order("created_at ASC").
+		Find(&collaborators).
+		Error
+	return collaborators, err
+}
+
+// getCollaboratorIDs returns the IDs of professors holding at least minRole on an opportunity
+func getCollaboratorIDs(db *gorm.DB, opportunityID uint, minRole string) ([]uint, error) {
+	roles := []string{}
+	for role := range collaboratorRank {
+		if RoleAtLeast(role, minRole) {
+			roles = append(roles, role)
+		}
+	}
+
+	var ids []uint
+	err := db.Model(&OpportunityCollaborator{}).
+		Where("opportunity_id = ? AND role IN ?", opportunityID, roles).
+		Pluck("professor_id", &ids).
+		Error
+	return ids, err
+}
+
+// AddCollaborator invites a professor onto an opportunity and notifies them
+func AddCollaborator(db *gorm.DB, opportunityID, professorID uint, role string) (*OpportunityCollaborator, error) {
+	if _, ok := collaboratorRank[role]; !ok {
+		return nil, fmt.Errorf("%w: invalid collaborator role", gorm.ErrInvalidValue)
+	}
+
+	op, err := GetOpportunityByID(db, opportunityID)
+	if err != nil {
+		return nil, err
+	}
+	if op.ProfessorID == nil {
+		return nil, errors.New("collaborators can only be added to professor-owned opportunities")
+	}
+	if _, err := GetProfessorByID(db, professorID); err != nil {
+		return nil, err
+	}
+
+	existing, err := GetCollaboratorRole(db, opportunityID, professorID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != "" {
+		return nil, errors.New("professor is already a collaborator")
+	}
+
+	collaborator := &OpportunityCollaborator{
+		OpportunityID: opportunityID,
+		ProfessorID:   professorID,
+		Role:          role,
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(collaborator).Error; err != nil {
+			return err
+		}
+		return NotifyCollaboratorAdded(tx, professorID, op.Name, role)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.Preload("Professor").First(collaborator, collaborator.ID)
+	return collaborator, nil
+}
+
+// UpdateCollaboratorRole changes a collaborator's role, keeping at least one owner
+func UpdateCollaboratorRole(db *gorm.DB, opportunityID, professorID uint, role string) (*OpportunityCollaborator, error) {
+	if _, ok := collaboratorRank[role]; !ok {
+		return nil, fmt.Errorf("%w: invalid collaborator role", gorm.ErrInvalidValue)
+	}
+
+	var collaborator OpportunityCollaborator
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("opportunity_id = ? AND professor_id = ?", opportunityID, professorID).First(&collaborator).Error; err != nil {
+			return errors.New("collaborator not found")
+		}
+		if collaborator.Role == CollaboratorOwner && role != CollaboratorOwner {
+			if err := ensureAnotherOwner(tx, opportunityID, professorID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&collaborator).Update("role", role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.Preload("Professor").First(&collaborator, collaborator.ID)
+	return &collaborator, nil
+}
+
+// RemoveCollaborator removes a professor from an opportunity, keeping at least one owner
+func RemoveCollaborator(db *gorm.DB, opportunityID, professorID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var collaborator OpportunityCollaborator
+		if err := tx.Where("opportunity_id = ? AND professor_id = ?", opportunityID, professorID).First(&collaborator).Error; err != nil {
+			return errors.New("collaborator not found")
+		}
+		if collaborator.Role == CollaboratorOwner {
+			if err := ensureAnotherOwner(tx, opportunityID, professorID); err != nil {
+				return err
+			}
+		}
+		// Hard delete so the professor can be invited again
+		return tx.Unscoped().Delete(&collaborator).Error
+	})
+}
+
+func ensureAnotherOwner(db *gorm.DB, opportunityID, professorID uint) error {
+	var owners int64
+	if err := db.Model(&OpportunityCollaborator{}).
+		Where("opportunity_id = ? AND role = ? AND professor_id <> ?", opportunityID, CollaboratorOwner, professorID).
+		Count(&owners).Error; err != nil {
+		return err
+	}
+	if owners == 0 {
+		return errors.New("an opportunity must keep at least one owner")
+	}
+	return nil
+}
+
+// BackfillOpportunityOwners makes each opportunity's creator an owner if they have no collaborator row yet
+func BackfillOpportunityOwners(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO opportunity_collaborators (created_at, updated_at, opportunity_id, professor_id, role)
+		SELECT NOW(), NOW(), o.id, o.professor_id, ?
+		FROM opportunities o
+		WHERE o.deleted_at IS NULL AND o.professor_id IS NOT NULL
+		ON CONFLICT (opportunity_id, professor_id) DO NOTHING`,
+		CollaboratorOwner,
+	).Error
+}
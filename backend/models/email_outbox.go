@@ -0,0 +1,208 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
+	"gorm.io/gorm"
+)
+
+// ErrAddressSuppressed is recorded on outbox emails dropped because their address bounced or complained
+var ErrAddressSuppressed = errors.New("address suppressed")
+
+const (
+	OutboxPending = "pending"
+	OutboxSending = "sending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead" // gave up after too many failed attempts
+)
+
+// OutboxEmail is an email waiting to be sent. Rows are written in the same transaction as the change
+// that triggered them and drained by the outbox workers, so a provider outage delays mail instead of
+// failing requests or losing messages.
+type OutboxEmail struct {
+	ID            uint              `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	To            string            `json:"to" gorm:"not null"`
+	Template      string            `json:"template" gorm:"not null"`
+	Subject       string            `json:"subject" gorm:"not null"`
+	Text          string            `json:"-" gorm:"type:text;not null"`
+	HTML          string            `json:"-" gorm:"type:text;not null"`
+	Headers       map[string]string `json:"-" gorm:"serializer:json;type:text"`
+	Status        string            `json:"status" gorm:"type:TEXT CHECK(status IN ('pending','sending','sent','dead'));not null;default:'pending';index:idx_email_outbox_due,priority:1"`
+	NextAttemptAt time.Time         `json:"next_attempt_at" gorm:"not null;index:idx_email_outbox_due,priority:2"`
+	Attempts      int               `json:"attempts" gorm:"not null;default:0"`
+	LockedAt      *time.Time        `json:"locked_at"`
+	LastError     string            `json:"last_error"`
+	Provider      string            `json:"provider"`
+	SentAt        *time.Time        `json:"sent_at"`
+}
+
+func (OutboxEmail) TableName() string {
+	return "email_outbox"
+}
+
+// EnqueueEmail renders a template and queues it for delivery. Pass the transaction making the
+// related change so the email is only sent if that change commits. Emails to suppressed
+// addresses are dropped. unsubscribe, if not nil, adds a footer link and List-Unsubscribe headers.
+func EnqueueEmail(tx *gorm.DB, to, recipient, template, locale string, data any, unsubscribe *mail_service.Unsubscribe) error {
+	suppressed, err := IsEmailSuppressed(tx, to)
+	if err != nil {
+		return err
+	}
+	if suppressed {
+		log.Printf("outbox: not queueing %s email to suppressed address %s", template, to)
+		return nil
+	}
+	email, err := mail_service.Templates.Render(template, locale, recipient, data, unsubscribe)
+	if err != nil {
+		return err
+	}
+	var headers map[string]string
+	if unsubscribe != nil {
+		headers = mail_service.UnsubscribeHeaders(*unsubscribe)
+	}
+	return tx.Create(&OutboxEmail{
+		To:            to,
+		Template:      template,
+		Subject:       email.Subject,
+		Text:          email.Text,
+		HTML:          email.HTML,
+		Headers:       headers,
+		Status:        OutboxPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// ClaimOutboxEmails locks up to limit due emails for one worker. Emails stuck in sending for longer
+// than staleAfter (a worker died mid-send) are claimed again. SKIP LOCKED lets workers on every
+// replica drain the outbox without waiting on each other.
+func ClaimOutboxEmails(db *gorm.DB, limit int, staleAfter time.Duration) ([]OutboxEmail, error) {
+	now := time.Now()
+	emails := []OutboxEmail{}
+	err := db.Raw(`
+		UPDATE email_outbox SET status = ?, locked_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE (status = ? AND next_attempt_at <= ?) OR (status = ? AND locked_at < ?)
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		OutboxSending, now, now,
+		OutboxPending, now, OutboxSending, now.Add(-staleAfter),
+		limit,
+	).Scan(&emails).Error
+	return emails, err
+}
+
+// MarkOutboxSent records a successful delivery
+func MarkOutboxSent(db *gorm.DB, id uint, provider string) error {
+	now := time.Now()
+	return db.Model(&OutboxEmail{ID: id}).Updates(map[string]interface{}{
+		"status":     OutboxSent,
+		"provider":   provider,
+		"sent_at":    &now,
+		"locked_at":  nil,
+		"last_error": "",
+	}).Error
+}
+
+// MarkOutboxFailed schedules another attempt at retryAt, or dead-letters the email if retryAt is nil
+func MarkOutboxFailed(db *gorm.DB, id uint, provider string, sendErr error, retryAt *time.Time) error {
+	updates := map[string]interface{}{
+		"status":     OutboxDead,
+		"provider":   provider,
+		"locked_at":  nil,
+		"last_error": sendErr.Error(),
+	}
+	if retryAt != nil {
+		updates["status"] = OutboxPending
+		updates["next_attempt_at"] = *retryAt
+	}
+	return db.Model(&OutboxEmail{ID: id}).Updates(updates).Error
+}
+
+// PurgeSentOutboxEmails permanently deletes emails sent more than maxAge ago, in batches. Their
+// bodies can hold verification codes and other one-time secrets, so they are not kept for long.
+func PurgeSentOutboxEmails(db *gorm.DB, maxAge time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-maxAge)
+	var purged int64
+	for {
+		result := db.Exec(`
+			DELETE FROM email_outbox WHERE id IN (
+				SELECT id FROM email_outbox WHERE status = ? AND sent_at < ? LIMIT 5000
+			)`, OutboxSent, cutoff)
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+		if result.RowsAffected < 5000 {
+			return purged, nil
+		}
+	}
+}
+
+// MailRateSlot is the next free send slot for a provider, shared by the outbox workers on every replica
+type MailRateSlot struct {
+	Provider string    `gorm:"primaryKey"`
+	NextAt   time.Time `gorm:"not null"`
+}
+
+// ReserveMailSlot claims the provider's next send slot, interval after the previous one, and returns how
+// long to wait for it. Slots are handed out by a single upsert on the database clock, so all replicas
+// together stay within the provider's rate.
+func ReserveMailSlot(db *gorm.DB, provider string, interval time.Duration) (time.Duration, error) {
+	var wait []float64
+	err := db.Raw(`
+		INSERT INTO mail_rate_slots (provider, next_at) VALUES (?, now() + make_interval(secs => ?))
+		ON CONFLICT (provider) DO UPDATE SET next_at = GREATEST(mail_rate_slots.next_at, now()) + make_interval(secs => ?)
+		RETURNING EXTRACT(EPOCH FROM next_at - now())::float8`,
+		provider, interval.Seconds(), interval.Seconds(),
+	).Scan(&wait).Error
+	if err != nil || len(wait) == 0 {
+		return 0, err
+	}
+	return max(time.Duration(wait[0]*float64(time.Second))-interval, 0), nil
+}
+
+// GetOutboxCounts returns how many emails are in each status
+func GetOutboxCounts(db *gorm.DB) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Model(&OutboxEmail{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := map[string]int64{OutboxPending: 0, OutboxSending: 0, OutboxSent: 0, OutboxDead: 0}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// GetDeadOutboxEmails lists dead-lettered emails, newest first
+func GetDeadOutboxEmails(db *gorm.DB, limit int) ([]OutboxEmail, error) {
+	emails := []OutboxEmail{}
+	err := db.Where("status = ?", OutboxDead).Order("updated_at DESC").Limit(limit).Find(&emails).Error
+	return emails, err
+}
+
+// RetryOutboxEmail puts a dead-lettered email back in the queue with a fresh set of attempts
+func RetryOutboxEmail(db *gorm.DB, id uint) error {
+	result := db.Model(&OutboxEmail{}).
+		Where("id = ? AND status = ?", id, OutboxDead).
+		Updates(map[string]interface{}{"status": OutboxPending, "attempts": 0, "next_attempt_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("dead-lettered email not found")
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// softBounceLimit is how many soft bounces suppress an address
+const softBounceLimit = 3
+
+// ErrSuppressionPermanent is returned when a user tries to lift a suppression caused by a hard bounce or complaint
+var ErrSuppressionPermanent = errors.New("this address bounced permanently or reported our email as spam, so we no longer email it")
+
+// EmailSuppression tracks bounces and spam complaints for an address. While SuppressedAt is set,
+// no email is sent to it.
+type EmailSuppression struct {
+	ID           uint       `json:"-" gorm:"primarykey"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Email        string     `json:"email" gorm:"not null;uniqueIndex"` // lowercased
+	HardBounces  int        `json:"hard_bounces" gorm:"not null;default:0"`
+	SoftBounces  int        `json:"soft_bounces" gorm:"not null;default:0"` // in a row: reset by a delivery or by the user clearing the suppression
+	Complaints   int        `json:"complaints" gorm:"not null;default:0"`
+	LastEvent    string     `json:"last_event"`
+	LastReason   string     `json:"last_reason"`
+	LastSource   string     `json:"last_source"` // which webhook reported it
+	LastEventAt  time.Time  `json:"last_event_at"`
+	SuppressedAt *time.Time `json:"suppressed_at"`
+}
+
+// RecordEmailEvents adds reported bounces and complaints to each address's history. Hard bounces and
+// complaints suppress the address immediately; soft bounces after softBounceLimit in a row, where a
+// successful delivery in between starts the count again.
+func RecordEmailEvents(db *gorm.DB, source string, events []mail_service.DeliveryEvent) error {
+	// Providers batch events loosely; a delivery only breaks a run of bounces that came before it
+	events = slices.Clone(events)
+	slices.SortStableFunc(events, func(a, b mail_service.DeliveryEvent) int { return a.At.Compare(b.At) })
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, event := range events {
+			email := strings.ToLower(strings.TrimSpace(event.Email))
+			if event.Kind == mail_service.Delivered {
+				// Only addresses with a bounce history have a row worth touching
+				if err := tx.Model(&EmailSuppression{}).
+					Where("email = ? AND soft_bounces > 0 AND suppressed_at IS NULL", email).
+					Update("soft_bounces", 0).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			row := EmailSuppression{
+				Email:       email,
+				LastEvent:   event.Kind,
+				LastReason:  event.Reason,
+				LastSource:  source,
+				LastEventAt: event.At,
+			}
+			switch event.Kind {
+			case mail_service.HardBounce:
+				row.HardBounces = 1
+			case mail_service.SoftBounce:
+				row.SoftBounces = 1
+			case mail_service.Complaint:
+				row.Complaints = 1
+			}
+			if row.HardBounces > 0 || row.Complaints > 0 {
+				row.SuppressedAt = &event.At
+			}
+
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "email"}},
+				DoUpdates: clause.Set{
+					{Column: clause.Column{Name: "hard_bounces"}, Value: gorm.Expr("email_suppressions.hard_bounces + EXCLUDED.hard_bounces")},
+					{Column: clause.Column{Name: "soft_bounces"}, Value: gorm.Expr("email_suppressions.soft_bounces + EXCLUDED.soft_bounces")},
+					{Column: clause.Column{Name: "complaints"}, Value: gorm.Expr("email_suppressions.complaints + EXCLUDED.complaints")},
+					{Column: clause.Column{Name: "last_event"}, Value: gorm.Expr("EXCLUDED.last_event")},
+					{Column: clause.Column{Name: "last_reason"}, Value: gorm.Expr("EXCLUDED.last_reason")},
+					{Column: clause.Column{Name: "last_source"}, Value: gorm.Expr("EXCLUDED.last_source")},
+					{Column: clause.Column{Name: "last_event_at"}, Value: gorm.Expr("EXCLUDED.last_event_at")},
+					{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("EXCLUDED.updated_at")},
+					{Column: clause.Column{Name: "suppressed_at"}, Value: gorm.Expr(
+						`COALESCE(email_suppressions.suppressed_at, EXCLUDED.suppressed_at,
+							CASE WHEN email_suppressions.soft_bounces + EXCLUDED.soft_bounces >= ? THEN EXCLUDED.last_event_at END)`,
+						softBounceLimit,
+					)},
+				},
+			}).Create(&row).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetEmailSuppression returns an address's bounce history, or nil if it never bounced
+func GetEmailSuppression(db *gorm.DB, email string) (*EmailSuppression, error) {
+	var suppression EmailSuppression
+	err := db.Where("email = ?", strings.ToLower(strings.TrimSpace(email))).First(&suppression).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+// IsEmailSuppressed reports whether mail to an address must not be sent
+func IsEmailSuppressed(db *gorm.DB, email string) (bool, error) {
+	var count int64
+	err := db.Model(&EmailSuppression{}).
+		Where("email = ? AND suppressed_at IS NOT NULL", strings.ToLower(strings.TrimSpace(email))).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Clearable reports whether the address's owner may lift the suppression themselves. Only soft bounces
+// (a full mailbox, a server that was down) can be fixed in place; after a hard bounce or a spam complaint
+// mail only resumes to a different address, which has its own history.
+func (s EmailSuppression) Clearable() bool {
+	return s.HardBounces == 0 && s.Complaints == 0
+}
+
+// ClearEmailSuppression resumes sending to an address after its owner says the problem is fixed.
+// The history is kept. Suppressions that are not Clearable return ErrSuppressionPermanent.
+func ClearEmailSuppression(db *gorm.DB, email string) error {
+	result := db.Model(&EmailSuppression{}).
+		Where("email = ? AND suppressed_at IS NOT NULL", strings.ToLower(strings.TrimSpace(email))).
+		Where("hard_bounces = 0 AND complaints = 0").
+		Updates(map[string]interface{}{"suppressed_at": nil, "soft_bounces": 0})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Either nothing to clear or a permanent suppression
+		if suppressed, err := IsEmailSuppressed(db, email); err != nil {
+			return err
+		} else if suppressed {
+			return ErrSuppressionPermanent
+		}
+	}
+	return nil
+}
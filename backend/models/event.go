@@ -1,22 +1,116 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Event location modes
+const (
+	EventOnline   = "online"
+	EventInPerson = "in_person"
+)
+
+// Event filters on GetEvents
+const (
+	EventsUpcoming = "upcoming"
+	EventsPast     = "past"
+)
+
 type Event struct {
 	gorm.Model
 	Title          string       `json:"title"`
 	Description    string       `json:"description"`
-	Date           string       `json:"date"`         // Can be a date, duration, or date range as string
+	Date           string       `json:"date"`                                   // Free-form date from before StartsAt existed, shown when it could not be parsed
+	StartsAt       *time.Time   `json:"starts_at" gorm:"index"`                 // nil for old events whose date could not be parsed
+	EndsAt         *time.Time   `json:"ends_at"`                                // exclusive; for all-day events, midnight after the last day
+	Timezone       string       `json:"timezone" gorm:"not null;default:'UTC'"` // IANA name the event is held in
+	AllDay         bool         `json:"all_day" gorm:"not null;default:false"`
+	LocationMode   string       `json:"location_mode" gorm:"type:TEXT CHECK(location_mode IN ('','online','in_person'));not null;default:''"`
+	Location       string       `json:"location"`     // Address or meeting link
 	Link           string       `json:"link"`         // Learn more link
 	SignUpLink     string       `json:"sign_up_link"` // Registration/signup form link
 	OrganizationID uint         `json:"organization_id"`
 	Organization   Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
 }
 
+// EventFilter narrows GetEvents. When is upcoming (not yet ended), past or empty for all; From and Until
+// keep events overlapping that range. Filtering leaves out events without a parsed StartsAt.
+type EventFilter struct {
+	When  string
+	From  *time.Time
+	Until *time.Time
+}
+
+// normalizeSchedule validates an event's time and place. All-day events are stretched to whole days in
+// their timezone.
+func (e *Event) normalizeSchedule() error {
+	if e.Timezone == "" {
+		e.Timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", gorm.ErrInvalidValue, e.Timezone)
+	}
+	if e.LocationMode != "" && e.LocationMode != EventOnline && e.LocationMode != EventInPerson {
+		return fmt.Errorf("%w: location_mode must be online or in_person", gorm.ErrInvalidValue)
+	}
+
+	// clients that only send the free-form date still get a schedule when it can be read
+	if e.StartsAt == nil && e.EndsAt == nil && e.Date != "" {
+		if start, end, allDay, ok := ParseEventDate(e.Date, loc); ok {
+			e.StartsAt, e.EndsAt, e.AllDay = &start, end, allDay
+		}
+	}
+	if e.StartsAt == nil {
+		if e.EndsAt != nil {
+			return fmt.Errorf("%w: ends_at requires starts_at", gorm.ErrInvalidValue)
+		}
+		return nil
+	}
+	if e.EndsAt != nil && e.EndsAt.Before(*e.StartsAt) {
+		return fmt.Errorf("%w: ends_at must not be before starts_at", gorm.ErrInvalidValue)
+	}
+
+	if e.AllDay {
+		start := e.StartsAt.In(loc)
+		start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+		end := start.AddDate(0, 0, 1)
+		if e.EndsAt != nil {
+			last := e.EndsAt.In(loc)
+			end = time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, loc)
+			if end.Before(last) {
+				end = end.AddDate(0, 0, 1)
+			}
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+		}
+		e.StartsAt, e.EndsAt = &start, &end
+	}
+	return nil
+}
+
+// EventAttendance records that a student attended an event, as confirmed by the organization
+type EventAttendance struct {
+	gorm.Model
+	EventID   uint     `json:"event_id" gorm:"not null;uniqueIndex:idx_event_attendance"`
+	Event     *Event   `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:EventID;references:ID"`
+	StudentID uint     `json:"student_id" gorm:"not null;uniqueIndex:idx_event_attendance"`
+	Student   *Student `json:"student,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:StudentID;references:ID"`
+	// Coins granted by this request; 0 if already rewarded or the organization reached its limit
+	CoinsAwarded int `json:"coins_awarded" gorm:"-"`
+}
+
 // CreateEvent creates a new event
 func CreateEvent(db *gorm.DB, event *Event) error {
+	if err := event.normalizeSchedule(); err != nil {
+		return err
+	}
 	return db.Create(event).Error
 }
 
@@ -30,10 +124,40 @@ func GetEventByID(db *gorm.DB, id uint) (*Event, error) {
 	return &event, nil
 }
 
-// GetAllEvents retrieves all events
-func GetAllEvents(db *gorm.DB) ([]Event, error) {
+// GetEvents retrieves events, newest first. Filtered events are ordered by when they start: soonest
+// first, or most recent first for past events.
+func GetEvents(db *gorm.DB, filter EventFilter) ([]Event, error) {
+	query := db.Preload("Organization")
+
+	// an event without an end is over once it starts
+	ends := "COALESCE(ends_at, starts_at)"
+	switch filter.When {
+	case "":
+	case EventsUpcoming:
+		query = query.Where(ends+" > ?", time.Now())
+	case EventsPast:
+		query = query.Where(ends+" <= ?", time.Now())
+	default:
+		return nil, fmt.Errorf("%w: when must be upcoming or past", gorm.ErrInvalidValue)
+	}
+	if filter.From != nil {
+		query = query.Where(ends+" > ?", *filter.From)
+	}
+	if filter.Until != nil {
+		query = query.Where("starts_at < ?", *filter.Until)
+	}
+
+	switch {
+	case filter.When == EventsPast:
+		query = query.Order("starts_at desc")
+	case filter.When != "" || filter.From != nil || filter.Until != nil:
+		query = query.Order("starts_at asc")
+	default:
+		query = query.Order("created_at desc")
+	}
+
 	var events []Event
-	err := db.Preload("Organization").Order("created_at desc").Find(&events).Error
+	err := query.Find(&events).Error
 	return events, err
 }
 
@@ -46,6 +170,9 @@ func GetEventsByOrganizationID(db *gorm.DB, orgID uint) ([]Event, error) {
 
 // UpdateEvent updates an existing event
 func UpdateEvent(db *gorm.DB, event *Event) error {
+	if err := event.normalizeSchedule(); err != nil {
+		return err
+	}
 	return db.Save(event).Error
 }
 
@@ -62,3 +189,33 @@ func DeleteEventByIDAndOrg(db *gorm.DB, eventID, orgID uint) error {
 	}
 	return result.Error
 }
+
+// RecordEventAttendance marks a student as having attended an event and awards the attendance reward once,
+// within the organization's limit
+func RecordEventAttendance(db *gorm.DB, event *Event, studentID uint) (*EventAttendance, error) {
+	if _, err := GetStudentByID(db, studentID); err != nil {
+		return nil, err
+	}
+
+	attendance := EventAttendance{EventID: event.ID, StudentID: studentID}
+	if err := db.Where(&attendance).FirstOrCreate(&attendance).Error; err != nil {
+		return nil, err
+	}
+
+	transaction, err := AwardAttendanceCoins(db, event, studentID)
+	if err != nil && !errors.Is(err, ErrAlreadyAwarded) && !errors.Is(err, ErrGrantLimitReached) {
+		log.Printf("coin award for event %d attendance: %v", event.ID, err)
+	}
+	if transaction != nil {
+		attendance.CoinsAwarded = transaction.Amount
+	}
+
+	return &attendance, nil
+}
+
+// GetEventAttendance lists the students confirmed as attending an event
+func GetEventAttendance(db *gorm.DB, eventID uint) ([]EventAttendance, error) {
+	var attendance []EventAttendance
+	err := db.Preload("Student").Where("event_id = ?", eventID).Order("created_at ASC").Find(&attendance).Error
+	return attendance, err
+}
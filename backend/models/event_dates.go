@@ -0,0 +1,176 @@
+package models
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	eventDateOrdinals = regexp.MustCompile(`(?i)\b(\d{1,2})(st|nd|rd|th)\b`)
+	eventDateWeekdays = regexp.MustCompile(`(?i)\b(monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tue|tues|wed|thu|thur|thurs|fri|sat|sun)\b,?\s*`)
+	eventDateSpaces   = regexp.MustCompile(`\s+`)
+	eventDateYear     = regexp.MustCompile(`\b\d{4}$`)
+	// "March 15-17, 2026" and "15-17 March 2026"
+	eventMonthDayRange = regexp.MustCompile(`^([A-Z]+) (\d{1,2}) ?- ?(\d{1,2}),? (\d{4})$`)
+	eventDayMonthRange = regexp.MustCompile(`^(\d{1,2}) ?- ?(\d{1,2}) ([A-Z]+),? (\d{4})$`)
+	// "03/04/2026" and the like, read as day/month but possibly written month/day
+	eventNumericDate = regexp.MustCompile(`\b\d{1,2}[/.-]\d{1,2}[/.-]\d{4}\b`)
+)
+
+var eventDateLayouts = []string{
+	"2006-01-02", "2006/01/02", "02/01/2006", "2/1/2006", "02-01-2006", "02.01.2006",
+	"January 2, 2006", "January 2 2006", "Jan 2, 2006", "Jan 2 2006",
+	"2 January 2006", "2 January, 2006", "2 Jan 2006", "2 Jan, 2006",
+}
+
+var eventTimeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3 PM", "3PM"}
+
+// parseEventMoment reads a single date, with or without a time of day
+func parseEventMoment(s string, loc *time.Location) (t time.Time, hasTime, ok bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true, true
+	}
+	for _, date := range eventDateLayouts {
+		if t, err := time.ParseInLocation(date, s, loc); err == nil {
+			return t, false, true
+		}
+		for _, clock := range eventTimeLayouts {
+			for _, sep := range []string{" ", ", ", "T"} {
+				if t, err := time.ParseInLocation(date+sep+clock, s, loc); err == nil {
+					return t, true, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false, false
+}
+
+// parseEventClock reads a time of day and places it on day
+func parseEventClock(s string, day time.Time) (time.Time, bool) {
+	for _, clock := range eventTimeLayouts {
+		if t, err := time.Parse(clock, s); err == nil {
+			return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, day.Location()), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseEventDate turns the free-form dates events used to have into a schedule. It understands single
+// dates with an optional time ("March 15, 2026 2:00 PM", "15/03/2026"), ranges of dates or times
+// ("15 - 17 March 2026", "2026-03-15 14:00 to 16:00") and reads day/month/year numerically. Dates
+// without a time are all-day, ending at midnight after their last day. ok is false if s is not understood.
+func ParseEventDate(s string, loc *time.Location) (start time.Time, end *time.Time, allDay, ok bool) {
+	s = strings.NewReplacer("–", "-", "—", "-", " at ", " ", " AT ", " ").Replace(s)
+	s = eventDateOrdinals.ReplaceAllString(s, "$1")
+	s = eventDateWeekdays.ReplaceAllString(s, "")
+	s = strings.ToUpper(strings.TrimSpace(eventDateSpaces.ReplaceAllString(s, " ")))
+	if s == "" {
+		return time.Time{}, nil, false, false
+	}
+
+	if t, hasTime, ok := parseEventMoment(s, loc); ok {
+		if hasTime {
+			return t, nil, false, true
+		}
+		next := t.AddDate(0, 0, 1)
+		return t, &next, true, true
+	}
+
+	if m := eventMonthDayRange.FindStringSubmatch(s); m != nil {
+		return parseEventDayRange(m[1]+" "+m[2]+" "+m[4], m[1]+" "+m[3]+" "+m[4], loc)
+	}
+	if m := eventDayMonthRange.FindStringSubmatch(s); m != nil {
+		return parseEventDayRange(m[1]+" "+m[3]+" "+m[4], m[2]+" "+m[3]+" "+m[4], loc)
+	}
+
+	for _, sep := range []string{" - ", " TO ", " UNTIL ", "-"} {
+		left, right, found := strings.Cut(s, sep)
+		if !found {
+			continue
+		}
+		left, right = strings.TrimSpace(left), strings.TrimSpace(right)
+
+		from, fromTime, ok := parseEventMoment(left, loc)
+		if !ok {
+			// "March 15 - April 2, 2026": the year is only given once
+			if year := eventDateYear.FindString(right); year != "" {
+				from, fromTime, ok = parseEventMoment(strings.TrimSuffix(left, ",")+", "+year, loc)
+				if !ok {
+					from, fromTime, ok = parseEventMoment(strings.TrimSuffix(left, ",")+" "+year, loc)
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		to, toTime, ok := parseEventMoment(right, loc)
+		if !ok && fromTime {
+			to, ok = parseEventClock(right, from)
+			toTime = true
+		}
+		if !ok || fromTime != toTime {
+			continue
+		}
+		if !fromTime {
+			to = to.AddDate(0, 0, 1)
+		}
+		if !to.After(from) {
+			continue
+		}
+		return from, &to, !fromTime, true
+	}
+	return time.Time{}, nil, false, false
+}
+
+func parseEventDayRange(first, last string, loc *time.Location) (time.Time, *time.Time, bool, bool) {
+	from, _, ok := parseEventMoment(first, loc)
+	if !ok {
+		return time.Time{}, nil, false, false
+	}
+	to, _, ok := parseEventMoment(last, loc)
+	if !ok || to.Before(from) {
+		return time.Time{}, nil, false, false
+	}
+	to = to.AddDate(0, 0, 1)
+	return from, &to, true, true
+}
+
+// MigrateEventDates fills in the schedule of events created before StartsAt existed by parsing their
+// free-form Date in loc. Events whose Date cannot be understood keep showing it as text until edited.
+// Numeric dates are read day first; each one is logged so a month-first date can be fixed by hand.
+func MigrateEventDates(db *gorm.DB, loc *time.Location) error {
+	var events []Event
+	if err := db.Where("starts_at IS NULL AND date <> ''").Find(&events).Error; err != nil {
+		return err
+	}
+
+	unparsed := 0
+	for _, event := range events {
+		start, end, allDay, ok := ParseEventDate(event.Date, loc)
+		if !ok {
+			unparsed++
+			continue
+		}
+		err := db.Model(&event).Updates(map[string]interface{}{
+			"starts_at": start,
+			"ends_at":   end,
+			"all_day":   allDay,
+			"timezone":  loc.String(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if eventNumericDate.MatchString(event.Date) {
+			log.Printf("event dates: event %d: read numeric date %q as %s (day/month); check it", event.ID, event.Date, start.Format("2 January 2006"))
+		}
+	}
+	if unparsed > 0 {
+		log.Printf("event dates: %d of %d events have a date that could not be parsed", unparsed, len(events))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEventDate(t *testing.T) {
+	cairo := time.FixedZone("EET", 2*60*60)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, cairo) }
+	at := func(y int, m time.Month, d, h, min int) time.Time { return time.Date(y, m, d, h, min, 0, 0, cairo) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		in     string
+		start  time.Time
+		end    *time.Time
+		allDay bool
+	}{
+		// Single dates are all-day, ending at midnight after the day
+		{"2026-03-15", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+		{"2026/03/15", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+		{"March 15, 2026", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+		{"Mar 15 2026", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+		{"15 March 2026", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+		{"Sunday, March 15th, 2026", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+		{"  march   15,  2026 ", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+
+		// Numeric dates are day/month/year
+		{"15/03/2026", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+		{"3/4/2026", day(2026, 4, 3), ptr(day(2026, 4, 4)), true},
+		{"03/04/2026", day(2026, 4, 3), ptr(day(2026, 4, 4)), true},
+		{"15-03-2026", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+		{"15.03.2026", day(2026, 3, 15), ptr(day(2026, 3, 16)), true},
+
+		// Dates with a time of day have no end
+		{"March 15, 2026 2:00 PM", at(2026, 3, 15, 14, 0), nil, false},
+		{"March 15, 2026 at 2 PM", at(2026, 3, 15, 14, 0), nil, false},
+		{"2026-03-15 14:30", at(2026, 3, 15, 14, 30), nil, false},
+		{"2026-03-15T14:30", at(2026, 3, 15, 14, 30), nil, false},
+		{"15/03/2026 09:00", at(2026, 3, 15, 9, 0), nil, false},
+		{"2026-03-15T14:30:00Z", time.Date(2026, 3, 15, 14, 30, 0, 0, time.UTC), nil, false},
+
+		// Ranges of days
+		{"March 15-17, 2026", day(2026, 3, 15), ptr(day(2026, 3, 18)), true},
+		{"15 - 17 March 2026", day(2026, 3, 15), ptr(day(2026, 3, 18)), true},
+		{"15–17 March 2026", day(2026, 3, 15), ptr(day(2026, 3, 18)), true},
+		{"March 30 - April 2, 2026", day(2026, 3, 30), ptr(day(2026, 4, 3)), true},
+		{"2026-03-15 to 2026-03-17", day(2026, 3, 15), ptr(day(2026, 3, 18)), true},
+
+		// Ranges of times
+		{"2026-03-15 14:00 to 16:00", at(2026, 3, 15, 14, 0), ptr(at(2026, 3, 15, 16, 0)), false},
+		{"March 15, 2026 2 PM - 4 PM", at(2026, 3, 15, 14, 0), ptr(at(2026, 3, 15, 16, 0)), false},
+		{"2026-03-15 14:00 until 2026-03-16 10:00", at(2026, 3, 15, 14, 0), ptr(at(2026, 3, 16, 10, 0)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			start, end, allDay, ok := ParseEventDate(tt.in, cairo)
+			if !ok {
+				t.Fatalf("ParseEventDate(%q) not understood", tt.in)
+			}
+			if !start.Equal(tt.start) {
+				t.Errorf("start = %v, want %v", start, tt.start)
+			}
+			switch {
+			case tt.end == nil && end != nil:
+				t.Errorf("end = %v, want none", *end)
+			case tt.end != nil && (end == nil || !end.Equal(*tt.end)):
+				t.Errorf("end = %v, want %v", end, *tt.end)
+			}
+			if allDay != tt.allDay {
+				t.Errorf("allDay = %v, want %v", allDay, tt.allDay)
+			}
+		})
+	}
+}
+
+func TestParseEventDateRejects(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"TBA",
+		"next Tuesday",
+		"32/01/2026",
+		"2026-03-17 to 2026-03-15",  // ends before it starts
+		"2026-03-15 to 14:00",       // day mixed with a time
+		"2026-03-15 16:00 to 14:00", // ends before it starts
+	} {
+		if start, _, _, ok := ParseEventDate(in, time.UTC); ok {
+			t.Errorf("ParseEventDate(%q) = %v, want not understood", in, start)
+		}
+	}
+}
+
+func TestEventNumericDate(t *testing.T) {
+	tests := map[string]bool{
+		"03/04/2026":             true,
+		"3.4.2026":               true,
+		"15-03-2026 09:00":       true,
+		"2026-03-15":             false,
+		"March 15, 2026":         false,
+		"2026-03-15 to 16:00":    false,
+		"15 - 17 March 2026":     false,
+		"2026-03-15T14:30:00Z":   false,
+		"03/04/2026 to 05/04/26": true,
+	}
+	for in, want := range tests {
+		if got := eventNumericDate.MatchString(in); got != want {
+			t.Errorf("eventNumericDate.MatchString(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	LeaderboardAllTime  = "all_time"
+	LeaderboardSemester = "semester"
+	LeaderboardMonth    = "month"
+)
+
+// leaderboardMetrics maps the accepted ?by= values to their aggregate column
+var leaderboardMetrics = map[string]string{
+	"coins":        "coins_earned",
+	"applications": "accepted_applications",
+	"reports":      "reports_submitted",
+}
+
+// LeaderboardEntry is one ranked student. Only the first name and last initial are exposed, not the student ID.
+type LeaderboardEntry struct {
+	Rank                 int    `json:"rank"`
+	StudentID            uint   `json:"-"`
+	Name                 string `json:"name"`
+	CoinsEarned          int64  `json:"coins_earned"`
+	AcceptedApplications int64  `json:"accepted_applications"`
+	ReportsSubmitted     int64  `json:"reports_submitted"`
+}
+
+// Leaderboard is a ranking for one window, metric and optional tag
+type Leaderboard struct {
+	Window      string             `json:"window"`
+	By          string             `json:"by"`
+	Tag         *Tag               `json:"tag,omitempty"`
+	Since       *time.Time         `json:"since"`
+	RefreshedAt time.Time          `json:"refreshed_at"`
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+// EnableLeaderboard creates the materialized view of per-student daily activity the leaderboard reads from.
+// Coins earned only count credits, so spending or the pre-ledger opening balance never affects rank.
+func EnableLeaderboard(db *gorm.DB) error {
+	statements := []string{
+		// Views created before applications had accepted_at dated acceptances by updated_at; rebuild them
+		`DO $$ BEGIN
+			IF EXISTS (SELECT 1 FROM pg_matviews WHERE matviewname = 'student_activity_daily' AND definition NOT LIKE '%accepted_at%') THEN
+				DROP MATERIALIZED VIEW student_activity_daily;
+			END IF;
+		END $$`,
+		`CREATE MATERIALIZED VIEW IF NOT EXISTS student_activity_daily AS
+			SELECT student_id, day,
+				SUM(coins_earned)::bigint AS coins_earned,
+				SUM(accepted_applications)::bigint AS accepted_applications,
+				SUM(reports_submitted)::bigint AS reports_submitted
+			FROM (
+				SELECT student_id, created_at::date AS day, amount AS coins_earned, 0 AS accepted_applications, 0 AS reports_submitted
+				FROM coin_transactions WHERE amount > 0 AND reason <> 'opening_balance'
+				UNION ALL
+				SELECT student_id, accepted_at::date, 0, 1, 0
+				FROM applications WHERE status = 'accepted' AND accepted_at IS NOT NULL AND deleted_at IS NULL
+				UNION ALL
+				SELECT student_id, created_at::date, 0, 0, 1
+				FROM weekly_reports WHERE deleted_at IS NULL
+			) activity
+			GROUP BY student_id, day`,
+		// Required for REFRESH ... CONCURRENTLY
+		"CREATE UNIQUE INDEX IF NOT EXISTS idx_student_activity_daily ON student_activity_daily (student_id, day)",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return RefreshLeaderboard(db)
+}
+
+// leaderboardCacheTTL bounds how long a replica serves cached rankings. The view is refreshed by
+// the job leader, so other replicas only notice through their cached boards expiring.
+const leaderboardCacheTTL = time.Minute
+
+// leaderboardCache holds computed rankings until the next refresh of the materialized view
+var leaderboardCache = struct {
+	sync.RWMutex
+	refreshedAt time.Time
+	boards      map[string]*Leaderboard
+}{boards: map[string]*Leaderboard{}}
+
+// RefreshLeaderboard recomputes the materialized view without blocking readers and drops cached rankings
+func RefreshLeaderboard(db *gorm.DB) error {
+	if err := db.Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY student_activity_daily").Error; err != nil {
+		return err
+	}
+
+	leaderboardCache.Lock()
+	leaderboardCache.refreshedAt = time.Now()
+	leaderboardCache.boards = map[string]*Leaderboard{}
+	leaderboardCache.Unlock()
+	return nil
+}
+
+// SetLeaderboardOptOut hides or shows a student on the leaderboard, taking effect immediately
+func SetLeaderboardOptOut(db *gorm.DB, studentID uint, optOut bool) error {
+	if err := db.Model(&Student{}).Where("id = ?", studentID).Update("leaderboard_opt_out", optOut).Error; err != nil {
+		return err
+	}
+
+	leaderboardCache.Lock()
+	leaderboardCache.boards = map[string]*Leaderboard{}
+	leaderboardCache.Unlock()
+	return nil
+}
+
+// LeaderboardWindowStart returns when a window begins, or nil for all time.
+// Semesters start on February 1 (spring, through summer) and September 1 (fall, through January).
+func LeaderboardWindowStart(window string, now time.Time) (*time.Time, error) {
+	var start time.Time
+	switch window {
+	case LeaderboardAllTime:
+		return nil, nil
+	case LeaderboardMonth:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case LeaderboardSemester:
+		switch {
+		case now.Month() >= time.September:
+			start = time.Date(now.Year(), time.September, 1, 0, 0, 0, 0, time.UTC)
+		case now.Month() >= time.February:
+			start = time.Date(now.Year(), time.February, 1, 0, 0, 0, 0, time.UTC)
+		default:
+			start = time.Date(now.Year()-1, time.September, 1, 0, 0, 0, 0, time.UTC)
+		}
+	default:
+		return nil, fmt.Errorf("%w: window must be all_time, semester or month", gorm.ErrInvalidValue)
+	}
+	return &start, nil
+}
+
+// GetLeaderboard ranks students who have not opted out by the chosen metric (coins, applications or reports),
+// breaking ties with the other metrics. tag restricts the ranking to students with that tag on their profile.
+func GetLeaderboard(db *gorm.DB, window, by string, tag *Tag, limit int) (*Leaderboard, error) {
+	column, ok := leaderboardMetrics[by]
+	if !ok {
+		return nil, fmt.Errorf("%w: by must be coins, applications or reports", gorm.ErrInvalidValue)
+	}
+	since, err := LeaderboardWindowStart(window, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	var tagID uint
+	if tag != nil {
+		tagID = tag.ID
+	}
+	key := fmt.Sprintf("%s:%s:%d:%d", window, by, tagID, limit)
+
+	leaderboardCache.Lock()
+	if time.Since(leaderboardCache.refreshedAt) > leaderboardCacheTTL {
+		leaderboardCache.refreshedAt = time.Now()
+		leaderboardCache.boards = map[string]*Leaderboard{}
+	}
+	cached, hit := leaderboardCache.boards[key]
+	refreshedAt := leaderboardCache.refreshedAt
+	leaderboardCache.Unlock()
+	if hit {
+		return cached, nil
+	}
+
+	query := db.Table("student_activity_daily a").
+		Select(`a.student_id, s.first_name || ' ' || LEFT(s.last_name, 1) || '.' AS name,
+			SUM(a.coins_earned)::bigint AS coins_earned,
+			SUM(a.accepted_applications)::bigint AS accepted_applications,
+			SUM(a.reports_submitted)::bigint AS reports_submitted`).
+		Joins("JOIN students s ON s.id = a.student_id AND s.deleted_at IS NULL AND NOT s.leaderboard_opt_out").
+		Group("a.student_id, s.first_name, s.last_name").
+		Having("SUM(a." + column + ") > 0")
+	if since != nil {
+		query = query.Where("a.day >= ?", *since)
+	}
+	if tag != nil {
+		query = query.Where("EXISTS (SELECT 1 FROM student_tags st WHERE st.student_id = a.student_id AND st.tag_id = ?)", tag.ID)
+	}
+
+	order := []string{column}
+	for _, other := range []string{"coins_earned", "accepted_applications", "reports_submitted"} {
+		if other != column {
+			order = append(order, other)
+		}
+	}
+	entries := []LeaderboardEntry{}
+	if err := query.
+		Order(order[0] + " DESC, " + order[1] + " DESC, " + order[2] + " DESC, a.student_id").
+		Limit(limit).
+		Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	// Students with equal scores on every metric share a rank
+	for i := range entries {
+		entries[i].Rank = i + 1
+		if i > 0 && entries[i].CoinsEarned == entries[i-1].CoinsEarned &&
+			entries[i].AcceptedApplications == entries[i-1].AcceptedApplications &&
+			entries[i].ReportsSubmitted == entries[i-1].ReportsSubmitted {
+			entries[i].Rank = entries[i-1].Rank
+		}
+	}
+
+	board := &Leaderboard{
+		Window:      window,
+		By:          by,
+		Tag:         tag,
+		Since:       since,
+		RefreshedAt: refreshedAt,
+		Entries:     entries,
+	}
+
+	leaderboardCache.Lock()
+	// Skip caching if a refresh happened while we were querying
+	if leaderboardCache.refreshedAt.Equal(refreshedAt) {
+		leaderboardCache.boards[key] = board
+	}
+	leaderboardCache.Unlock()
+	return board, nil
+}
@@ -3,8 +3,11 @@ package models
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
 	"gorm.io/gorm"
 )
 
@@ -12,29 +15,48 @@ import (
 type Notification struct {
 	gorm.Model
 	RecipientID uint   `json:"recipient_id" gorm:"not null"`
-	RecipientRole string `json:"recipient_role" gorm:"type:TEXT CHECK(recipient_role IN ('student','professor'));not null"`
+	RecipientRole string `json:"recipient_role" gorm:"type:TEXT CHECK(recipient_role IN ('student','professor','organization'));not null"`
 	Title       string `json:"title" gorm:"not null"`
 	Message     string `json:"message" gorm:"type:text;not null"`
 	Type        string `json:"type" gorm:"type:TEXT CHECK(type IN ('info','success','warning','error'));default:'info'"`
+	EventType   string `json:"event_type"` // what happened, e.g. application_status; see NotificationPreference
+	Digest      bool   `json:"-" gorm:"not null;default:false"` // also waiting to go out in the recipient's email digest
+	// Set once the notification has been included in a sent digest
+	DigestDeliveryID *uint `json:"-" gorm:"index"`
 	Read        bool   `json:"read" gorm:"default:false"`
 	ReadAt      *time.Time `json:"read_at"`
 }
 
-// CreateNotification creates a new notification
-func CreateNotification(db *gorm.DB, recipientID uint, recipientRole, title, message, notifType string) (*Notification, error) {
-	// Validate recipient role
-	if recipientRole != "student" && recipientRole != "professor" {
-		return nil, errors.New("invalid recipient role")
-	}
+// NotificationRecipientRoles lists every account type that can receive notifications
+var NotificationRecipientRoles = []string{"student", "professor", "organization"}
 
-	// Validate type
-	if notifType == "" {
-		notifType = "info"
-	}
-	if notifType != "info" && notifType != "success" && notifType != "warning" && notifType != "error" {
-		return nil, errors.New("invalid notification type")
+// IsNotificationRecipientRole reports whether role can receive notifications
+func IsNotificationRecipientRole(role string) bool {
+	for _, r := range NotificationRecipientRoles {
+		if r == role {
+			return true
+		}
 	}
+	return false
+}
+
+// MigrateNotificationRecipientRoles widens the recipient_role CHECK on databases created when only
+// students and professors could be notified. AutoMigrate does not rewrite existing CHECK constraints.
+func MigrateNotificationRecipientRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_recipient_role_check").Error; err != nil {
+			return err
+		}
+		// DDL cannot take bind parameters; the roles are constants
+		return tx.Exec(fmt.Sprintf(
+			"ALTER TABLE notifications ADD CONSTRAINT notifications_recipient_role_check CHECK (recipient_role IN ('%s'))",
+			strings.Join(NotificationRecipientRoles, "','"),
+		)).Error
+	})
+}
 
+// CreateNotification creates a new notification
+func CreateNotification(db *gorm.DB, recipientID uint, recipientRole, title, message, notifType string) (*Notification, error) {
 	notification := &Notification{
 		RecipientID:   recipientID,
 		RecipientRole: recipientRole,
@@ -43,32 +65,117 @@ func CreateNotification(db *gorm.DB, recipientID uint, recipientRole, title, mes
 		Type:          notifType,
 		Read:          false,
 	}
-
-	if err := db.Create(notification).Error; err != nil {
+	if err := saveNotification(db, notification); err != nil {
 		return nil, err
 	}
-
 	return notification, nil
 }
 
-// GetNotificationsByRecipient returns all notifications for a specific user
-func GetNotificationsByRecipient(db *gorm.DB, recipientID uint, recipientRole string, unreadOnly bool) ([]Notification, error) {
-	var notifications []Notification
+// saveNotification validates and inserts a notification and announces it to live streams
+func saveNotification(db *gorm.DB, notification *Notification) error {
+	// Validate recipient role
+	if !IsNotificationRecipientRole(notification.RecipientRole) {
+		return errors.New("invalid recipient role")
+	}
+
+	// Validate type
+	if notification.Type == "" {
+		notification.Type = "info"
+	}
+	if t := notification.Type; t != "info" && t != "success" && t != "warning" && t != "error" {
+		return errors.New("invalid notification type")
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(notification).Error; err != nil {
+			return err
+		}
+		return announceNotification(tx, notification)
+	})
+}
+
+// NotificationFilter narrows and pages a recipient's notifications
+type NotificationFilter struct {
+	UnreadOnly bool
+	EventTypes []string            // any of these event types; empty for all
+	Since      *time.Time          // created at or after
+	Until      *time.Time          // created before
+	Before     *NotificationCursor // the previous page's next_before, nil for the first page
+	Limit      int
+}
+
+// NotificationCursor is the position of the last notification on a page. It carries the sort key
+// itself rather than an ID, so paging still works after that notification is deleted or purged.
+type NotificationCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// String encodes the cursor as "<created_at RFC3339>_<id>" for use in query strings
+func (c NotificationCursor) String() string {
+	return c.CreatedAt.UTC().Format(time.RFC3339Nano) + "_" + strconv.FormatUint(uint64(c.ID), 10)
+}
+
+// ParseNotificationCursor decodes a cursor produced by NotificationCursor.String
+func ParseNotificationCursor(s string) (*NotificationCursor, error) {
+	at, id, found := strings.Cut(s, "_")
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if !found || err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", gorm.ErrInvalidValue)
+	}
+	parsedID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid cursor", gorm.ErrInvalidValue)
+	}
+	return &NotificationCursor{CreatedAt: createdAt, ID: uint(parsedID)}, nil
+}
+
+// NotificationPage is one page of notifications. NextBefore is empty on the last page.
+type NotificationPage struct {
+	Items      []Notification `json:"items"`
+	NextBefore string         `json:"next_before,omitempty"`
+}
+
+// GetNotificationsByRecipient returns a page of a user's notifications, newest first
+func GetNotificationsByRecipient(db *gorm.DB, recipientID uint, recipientRole string, filter NotificationFilter) (*NotificationPage, error) {
+	notifications := []Notification{}
 	query := db.Where("recipient_id = ? AND recipient_role = ?", recipientID, recipientRole)
-	
-	if unreadOnly {
+
+	if filter.UnreadOnly {
 		query = query.Where("read = ?", false)
 	}
-	
-	err := query.Order("created_at DESC").Find(&notifications).Error
-	return notifications, err
+	if len(filter.EventTypes) > 0 {
+		query = query.Where("event_type IN ?", filter.EventTypes)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	if filter.Before != nil {
+		// Keyset on (created_at, id) so pages stay stable while new notifications arrive
+		query = query.Where("(created_at, id) < (?, ?)", filter.Before.CreatedAt, filter.Before.ID)
+	}
+
+	// Fetch one extra row to tell whether there is another page
+	if err := query.Order("created_at DESC, id DESC").Limit(filter.Limit + 1).Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	page := &NotificationPage{Items: notifications}
+	if len(notifications) > filter.Limit {
+		page.Items = notifications[:filter.Limit]
+		last := page.Items[len(page.Items)-1]
+		page.NextBefore = NotificationCursor{CreatedAt: last.CreatedAt, ID: last.ID}.String()
+	}
+	return page, nil
 }
 
 // MarkNotificationAsRead marks a notification as read
-func MarkNotificationAsRead(db *gorm.DB, notificationID, recipientID uint) error {
+func MarkNotificationAsRead(db *gorm.DB, notificationID, recipientID uint, recipientRole string) error {
 	now := time.Now()
 	result := db.Model(&Notification{}).
-		Where("id = ? AND recipient_id = ?", notificationID, recipientID).
+		Where("id = ? AND recipient_id = ? AND recipient_role = ?", notificationID, recipientID, recipientRole).
 		Updates(map[string]interface{}{
 			"read":    true,
 			"read_at": &now,
@@ -95,8 +202,8 @@ func MarkAllNotificationsAsRead(db *gorm.DB, recipientID uint, recipientRole str
 }
 
 // DeleteNotification deletes a notification
-func DeleteNotification(db *gorm.DB, notificationID, recipientID uint) error {
-	result := db.Where("id = ? AND recipient_id = ?", notificationID, recipientID).Delete(&Notification{})
+func DeleteNotification(db *gorm.DB, notificationID, recipientID uint, recipientRole string) error {
+	result := db.Where("id = ? AND recipient_id = ? AND recipient_role = ?", notificationID, recipientID, recipientRole).Delete(&Notification{})
 	if result.Error != nil {
 		return result.Error
 	}
@@ -106,6 +213,18 @@ func DeleteNotification(db *gorm.DB, notificationID, recipientID uint) error {
 	return nil
 }
 
+// DeleteNotifications deletes several of a user's notifications at once, ignoring IDs that are not theirs
+func DeleteNotifications(db *gorm.DB, notificationIDs []uint, recipientID uint, recipientRole string) (int64, error) {
+	result := db.Where("id IN ? AND recipient_id = ? AND recipient_role = ?", notificationIDs, recipientID, recipientRole).Delete(&Notification{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteReadNotifications deletes all of a user's read notifications
+func DeleteReadNotifications(db *gorm.DB, recipientID uint, recipientRole string) (int64, error) {
+	result := db.Where("recipient_id = ? AND recipient_role = ? AND read = ?", recipientID, recipientRole, true).Delete(&Notification{})
+	return result.RowsAffected, result.Error
+}
+
 // GetUnreadCount returns the count of unread notifications
 func GetUnreadNotificationCount(db *gorm.DB, recipientID uint, recipientRole string) (int64, error) {
 	var count int64
@@ -116,6 +235,32 @@ func GetUnreadNotificationCount(db *gorm.DB, recipientID uint, recipientRole str
 	return count, err
 }
 
+// IndexNotifications adds the composite index inbox queries filter and sort on.
+// gorm tags cannot include CreatedAt from the embedded gorm.Model, so it is created here.
+func IndexNotifications(db *gorm.DB) error {
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_notifications_inbox ON notifications (recipient_id, recipient_role, read, created_at)").Error
+}
+
+// PurgeReadNotifications permanently deletes read notifications older than maxAge, in batches
+// so a large backlog does not hold long locks. Unread notifications are always kept.
+func PurgeReadNotifications(db *gorm.DB, maxAge time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-maxAge)
+	var purged int64
+	for {
+		result := db.Exec(`
+			DELETE FROM notifications WHERE id IN (
+				SELECT id FROM notifications WHERE read AND created_at < ? LIMIT 5000
+			)`, cutoff)
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+		if result.RowsAffected < 5000 {
+			return purged, nil
+		}
+	}
+}
+
 // NotifyApplicationStatusChange notifies a student when their application status changes
 func NotifyApplicationStatusChange(db *gorm.DB, studentID uint, opportunityName, status string) error {
 	title := "Application Update"
@@ -129,14 +274,114 @@ func NotifyApplicationStatusChange(db *gorm.DB, studentID uint, opportunityName,
 		message = fmt.Sprintf("Your application for '%s' was not accepted this time", opportunityName)
 	}
 	
-	_, err := CreateNotification(db, studentID, "student", title, message, notifType)
-	return err
+	return Dispatch(db, NotificationEvent{
+		Type:          EventApplicationStatus,
+		RecipientID:   studentID,
+		RecipientRole: "student",
+		Title:         title,
+		Message:       message,
+		Level:         notifType,
+		Template:      "application_status",
+		TemplateData:  mail_service.ApplicationStatusData{OpportunityName: opportunityName, Status: status},
+	})
 }
 
 // NotifyNewReport notifies a professor when a student submits a weekly report
-func NotifyNewReport(db *gorm.DB, professorID uint, studentName string) error {
+func NotifyNewReport(db *gorm.DB, professorID uint, studentName string, year, week int) error {
 	title := "📝 Weekly Report Submitted"
-	message := fmt.Sprintf("%s submitted a weekly report", studentName)
-	_, err := CreateNotification(db, professorID, "professor", title, message, "info")
-	return err
+	message := fmt.Sprintf("%s submitted a weekly report for week %d, %d", studentName, week, year)
+	return Dispatch(db, NotificationEvent{
+		Type:          EventNewReport,
+		RecipientID:   professorID,
+		RecipientRole: "professor",
+		Title:         title,
+		Message:       message,
+		Level:         "info",
+		Template:      "new_report",
+		TemplateData:  mail_service.NewReportData{StudentName: studentName, Year: year, Week: week},
+	})
+}
+
+// NotifyReportReviewed notifies a student when their weekly report is approved or sent back
+func NotifyReportReviewed(db *gorm.DB, studentID uint, professorName string, year, week int, status string) error {
+	title := "✅ Weekly Report Approved"
+	message := fmt.Sprintf("%s approved your report for week %d, %d", professorName, week, year)
+	notifType := "success"
+	if status == ReportNeedsRevision {
+		title = "✏️ Weekly Report Needs Revision"
+		message = fmt.Sprintf("%s asked you to revise your report for week %d, %d", professorName, week, year)
+		notifType = "warning"
+	}
+	return Dispatch(db, NotificationEvent{Type: EventReportReview, RecipientID: studentID, RecipientRole: "student", Title: title, Message: message, Level: notifType})
+}
+
+// NotifyReportFeedback notifies a student when a professor comments on their weekly report
+func NotifyReportFeedback(db *gorm.DB, studentID uint, professorName string, year, week int) error {
+	title := "💬 New Report Feedback"
+	message := fmt.Sprintf("%s commented on your report for week %d, %d", professorName, week, year)
+	return Dispatch(db, NotificationEvent{Type: EventReportComment, RecipientID: studentID, RecipientRole: "student", Title: title, Message: message, Level: "info"})
+}
+
+// NotifyReportReply notifies a professor when a student replies on a weekly report thread
+func NotifyReportReply(db *gorm.DB, professorID uint, studentName string, year, week int) error {
+	title := "💬 New Report Reply"
+	message := fmt.Sprintf("%s replied on their report for week %d, %d", studentName, week, year)
+	return Dispatch(db, NotificationEvent{Type: EventReportComment, RecipientID: professorID, RecipientRole: "professor", Title: title, Message: message, Level: "info"})
+}
+
+// NotifyReportResubmitted notifies a professor when a student revises a report they sent back
+func NotifyReportResubmitted(db *gorm.DB, professorID uint, studentName string, year, week int) error {
+	title := "📝 Weekly Report Revised"
+	message := fmt.Sprintf("%s revised their report for week %d, %d", studentName, week, year)
+	return Dispatch(db, NotificationEvent{Type: EventNewReport, RecipientID: professorID, RecipientRole: "professor", Title: title, Message: message, Level: "info"})
+}
+
+// NotifyNewApplication notifies whoever manages an opportunity when a student applies:
+// its owning organization, or its professor owners and editors
+func NotifyNewApplication(db *gorm.DB, opportunityID uint, opportunityName, studentName string) error {
+	title := "📥 New Application"
+	message := fmt.Sprintf("%s applied to '%s'", studentName, opportunityName)
+
+	var op Opportunity
+	if err := db.Select("id", "organization_id").First(&op, opportunityID).Error; err != nil {
+		return err
+	}
+	if op.OrganizationID != nil {
+		return Dispatch(db, NotificationEvent{Type: EventNewApplication, RecipientID: *op.OrganizationID, RecipientRole: "organization", Title: title, Message: message, Level: "info"})
+	}
+
+	professorIDs, err := getCollaboratorIDs(db, opportunityID, CollaboratorEditor)
+	if err != nil {
+		return err
+	}
+	for _, professorID := range professorIDs {
+		if err := Dispatch(db, NotificationEvent{Type: EventNewApplication, RecipientID: professorID, RecipientRole: "professor", Title: title, Message: message, Level: "info"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NotifyRewardRedeemed notifies an organization when a student redeems one of its rewards
+func NotifyRewardRedeemed(db *gorm.DB, organizationID uint, rewardName, studentName string) error {
+	title := "🎟️ Reward Redeemed"
+	message := fmt.Sprintf("%s redeemed '%s'", studentName, rewardName)
+	return Dispatch(db, NotificationEvent{Type: EventRewardRedeemed, RecipientID: organizationID, RecipientRole: "organization", Title: title, Message: message, Level: "info"})
+}
+
+// NotifyCollaboratorAdded notifies a professor who was added to an opportunity
+func NotifyCollaboratorAdded(db *gorm.DB, professorID uint, opportunityName, role string) error {
+	title := "🤝 Added as Collaborator"
+	message := fmt.Sprintf("You were added to '%s' as %s", opportunityName, role)
+	return Dispatch(db, NotificationEvent{Type: EventCollaboratorAdded, RecipientID: professorID, RecipientRole: "professor", Title: title, Message: message, Level: "info"})
+}
+
+// NotifyCoinsAwarded notifies a student when they earn coins
+func NotifyCoinsAwarded(db *gorm.DB, studentID uint, amount int, reason string) error {
+	title := "🪙 Coins Earned"
+	message := fmt.Sprintf("You earned %d coins", amount)
+	if reason != "" {
+		message = fmt.Sprintf("You earned %d coins: %s", amount, reason)
+	}
+	return Dispatch(db, NotificationEvent{Type: EventCoinsAwarded, RecipientID: studentID, RecipientRole: "student", Title: title, Message: message, Level: "success"})
 }
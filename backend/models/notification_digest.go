@@ -0,0 +1,283 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestSetting is when a user wants to receive notifications routed to the digest channel.
+// Users without a row get a daily digest at 08:00 UTC.
+type DigestSetting struct {
+	gorm.Model
+	RecipientID   uint   `json:"recipient_id" gorm:"not null;uniqueIndex:idx_digest_setting_recipient"`
+	RecipientRole string `json:"recipient_role" gorm:"not null;uniqueIndex:idx_digest_setting_recipient"`
+	Frequency     string `json:"frequency" gorm:"type:TEXT CHECK(frequency IN ('daily','weekly'));not null;default:'daily'"`
+	Weekday       int    `json:"weekday" gorm:"not null;default:1"` // 0 = Sunday; weekly digests only
+	Hour          int    `json:"hour" gorm:"not null;default:8"`
+	Minute        int    `json:"minute" gorm:"not null;default:0"`
+	Timezone      string `json:"timezone" gorm:"not null;default:'UTC'"` // IANA name, e.g. Africa/Cairo
+}
+
+// DigestDelivery records a digest sent for one scheduled slot, so a slot is never sent twice
+type DigestDelivery struct {
+	ID                uint      `json:"id" gorm:"primarykey"`
+	CreatedAt         time.Time `json:"created_at"`
+	RecipientID       uint      `json:"recipient_id" gorm:"not null;uniqueIndex:idx_digest_delivery_slot"`
+	RecipientRole     string    `json:"recipient_role" gorm:"not null;uniqueIndex:idx_digest_delivery_slot"`
+	Slot              time.Time `json:"slot" gorm:"not null;uniqueIndex:idx_digest_delivery_slot"`
+	NotificationCount int       `json:"notification_count" gorm:"not null"`
+}
+
+// DigestSettingInput holds the editable digest schedule
+type DigestSettingInput struct {
+	Frequency string `json:"frequency" binding:"required"`
+	Weekday   int    `json:"weekday"`
+	Hour      int    `json:"hour"`
+	Minute    int    `json:"minute"`
+	Timezone  string `json:"timezone" binding:"required"`
+}
+
+func (in DigestSettingInput) validate() error {
+	if in.Frequency != DigestDaily && in.Frequency != DigestWeekly {
+		return fmt.Errorf("%w: frequency must be daily or weekly", gorm.ErrInvalidValue)
+	}
+	if in.Weekday < 0 || in.Weekday > 6 {
+		return fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6", gorm.ErrInvalidValue)
+	}
+	if in.Hour < 0 || in.Hour > 23 || in.Minute < 0 || in.Minute > 59 {
+		return fmt.Errorf("%w: invalid time of day", gorm.ErrInvalidValue)
+	}
+	if _, err := time.LoadLocation(in.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", gorm.ErrInvalidValue, in.Timezone)
+	}
+	return nil
+}
+
+// digestGroupTitles labels each event type's section in a digest email, per locale.
+// The empty event type titles notifications of any other type.
+var digestGroupTitles = map[string]map[string]string{
+	"en": {
+		EventApplicationStatus: "Application updates",
+		EventNewApplication:    "New applications",
+		EventNewReport:         "Weekly reports",
+		EventReportReview:      "Report reviews",
+		EventReportComment:     "Report discussions",
+		EventReportReminder:    "Report reminders",
+		EventOverdueReports:    "Overdue reports",
+		EventCoinsAwarded:      "Coins earned",
+		EventCollaboratorAdded: "Collaborations",
+		EventSavedSearchMatch:  "Saved search matches",
+		EventRewardRedeemed:    "Reward redemptions",
+		"":                     "Other notifications",
+	},
+	"ar": {
+		EventApplicationStatus: "تحديثات الطلبات",
+		EventNewApplication:    "طلبات جديدة",
+		EventNewReport:         "التقارير الأسبوعية",
+		EventReportReview:      "مراجعات التقارير",
+		EventReportComment:     "مناقشات التقارير",
+		EventReportReminder:    "تذكيرات التقارير",
+		EventOverdueReports:    "تقارير متأخرة",
+		EventCoinsAwarded:      "عملات مكتسبة",
+		EventCollaboratorAdded: "التعاونات",
+		EventSavedSearchMatch:  "نتائج البحث المحفوظ",
+		EventRewardRedeemed:    "استبدال المكافآت",
+		"":                     "إشعارات أخرى",
+	},
+}
+
+// GetDigestSetting returns a user's digest schedule, or the default if they never set one
+func GetDigestSetting(db *gorm.DB, recipientID uint, recipientRole string) (*DigestSetting, error) {
+	setting := DigestSetting{
+		RecipientID:   recipientID,
+		RecipientRole: recipientRole,
+		Frequency:     DigestDaily,
+		Weekday:       int(time.Monday),
+		Hour:          8,
+		Timezone:      "UTC",
+	}
+	err := db.Where("recipient_id = ? AND recipient_role = ?", recipientID, recipientRole).First(&setting).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// UpdateDigestSetting stores a user's digest schedule
+func UpdateDigestSetting(db *gorm.DB, recipientID uint, recipientRole string, input DigestSettingInput) (*DigestSetting, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	setting := DigestSetting{
+		RecipientID:   recipientID,
+		RecipientRole: recipientRole,
+		Frequency:     input.Frequency,
+		Weekday:       input.Weekday,
+		Hour:          input.Hour,
+		Minute:        input.Minute,
+		Timezone:      input.Timezone,
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "recipient_id"}, {Name: "recipient_role"}},
+		DoUpdates: clause.AssignmentColumns([]string{"frequency", "weekday", "hour", "minute", "timezone", "updated_at"}),
+	}).Create(&setting).Error; err != nil {
+		return nil, err
+	}
+	return GetDigestSetting(db, recipientID, recipientRole)
+}
+
+// LastSlot returns the most recent scheduled send time at or before now, and the one before it
+func (s DigestSetting) LastSlot(now time.Time) (slot, previous time.Time) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	slot = time.Date(local.Year(), local.Month(), local.Day(), s.Hour, s.Minute, 0, 0, loc)
+
+	days := 1
+	if s.Frequency == DigestWeekly {
+		days = 7
+		slot = slot.AddDate(0, 0, -((int(slot.Weekday()) - s.Weekday + 7) % 7))
+	}
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -days)
+	}
+	return slot, slot.AddDate(0, 0, -days)
+}
+
+// SendNotificationDigests emails every user whose digest slot has passed a summary of their unread
+// digest notifications, grouped by type. Each slot is claimed in digest_deliveries in the same
+// transaction that queues the email, so overlapping runs or replicas never send the same digest twice.
+func SendNotificationDigests(db *gorm.DB, now time.Time) error {
+	type pendingRecipient struct {
+		RecipientID   uint
+		RecipientRole string
+	}
+	var recipients []pendingRecipient
+	if err := db.Model(&Notification{}).
+		Distinct("recipient_id", "recipient_role").
+		Where("digest AND digest_delivery_id IS NULL AND NOT read").
+		Scan(&recipients).Error; err != nil {
+		return err
+	}
+
+	for _, r := range recipients {
+		if err := sendNotificationDigest(db, r.RecipientID, r.RecipientRole, now); err != nil {
+			log.Printf("digest for %s %d: %v", r.RecipientRole, r.RecipientID, err)
+		}
+	}
+	return nil
+}
+
+// errNothingToDigest rolls back a claimed slot that has no notifications due yet
+var errNothingToDigest = errors.New("nothing to digest")
+
+func sendNotificationDigest(db *gorm.DB, recipientID uint, recipientRole string, now time.Time) error {
+	setting, err := GetDigestSetting(db, recipientID, recipientRole)
+	if err != nil {
+		return err
+	}
+	slot, previous := setting.LastSlot(now)
+
+	delivery := DigestDelivery{RecipientID: recipientID, RecipientRole: recipientRole, Slot: slot.UTC()}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errNothingToDigest // already sent for this slot
+		}
+
+		var notifications []Notification
+		if err := tx.
+			Where("recipient_id = ? AND recipient_role = ? AND digest AND digest_delivery_id IS NULL AND NOT read AND created_at <= ?", recipientID, recipientRole, slot).
+			Order("event_type, created_at").
+			Find(&notifications).Error; err != nil {
+			return err
+		}
+		if len(notifications) == 0 {
+			return errNothingToDigest
+		}
+
+		ids := make([]uint, len(notifications))
+		for i, n := range notifications {
+			ids[i] = n.ID
+		}
+		if err := tx.Model(&Notification{}).Where("id IN ?", ids).Update("digest_delivery_id", delivery.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&delivery).Update("notification_count", len(notifications)).Error; err != nil {
+			return err
+		}
+
+		name, email, locale, err := recipientContact(tx, recipientID, recipientRole)
+		if err != nil {
+			return err
+		}
+		digest := buildDigest(setting.Frequency, previous, notifications, locale)
+		unsubscribe := &mail_service.Unsubscribe{RecipientID: recipientID, RecipientRole: recipientRole, Category: UnsubscribeDigest}
+		return EnqueueEmail(tx, email, name, "digest", locale, digest, unsubscribe)
+	})
+	if errors.Is(err, errNothingToDigest) {
+		return nil
+	}
+	return err
+}
+
+// buildDigest groups notifications by event type, in the order they are given, with titles in the recipient's locale
+func buildDigest(frequency string, since time.Time, notifications []Notification, locale string) mail_service.Digest {
+	digest := mail_service.Digest{Frequency: frequency, Since: since}
+	titles, ok := digestGroupTitles[locale]
+	if !ok {
+		titles = digestGroupTitles[mail_service.DefaultLocale]
+	}
+	groupIndex := map[string]int{}
+	for _, n := range notifications {
+		title, ok := titles[n.EventType]
+		if !ok {
+			title = titles[""]
+		}
+		i, ok := groupIndex[title]
+		if !ok {
+			i = len(digest.Groups)
+			groupIndex[title] = i
+			digest.Groups = append(digest.Groups, mail_service.DigestGroup{Title: title})
+		}
+		group := &digest.Groups[i]
+		group.Items = append(group.Items, mail_service.DigestItem{Title: n.Title, Message: n.Message, CreatedAt: n.CreatedAt})
+	}
+	return digest
+}
+
+// recipientContact returns the display name, email address and email language of a notification recipient
+func recipientContact(db *gorm.DB, recipientID uint, recipientRole string) (name, email, locale string, err error) {
+	switch recipientRole {
+	case "student":
+		var student Student
+		err = db.First(&student, recipientID).Error
+		return student.FirstName, student.Email, student.Locale, err
+	case "professor":
+		var professor Professor
+		err = db.First(&professor, recipientID).Error
+		return professor.FirstName, professor.Email, professor.Locale, err
+	case "organization":
+		var organization Organization
+		err = db.First(&organization, recipientID).Error
+		return organization.Name, organization.Email, organization.Locale, err
+	}
+	return "", "", "", fmt.Errorf("unknown recipient role %q", recipientRole)
+}
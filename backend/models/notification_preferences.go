@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+
+	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Notification event types users can configure
+const (
+	EventApplicationStatus = "application_status"
+	EventNewApplication    = "new_application"
+	EventNewReport         = "new_report"
+	EventReportReview      = "report_review"
+	EventReportComment     = "report_comment"
+	EventReportReminder    = "report_reminder"
+	EventOverdueReports    = "overdue_reports"
+	EventCoinsAwarded      = "coins_awarded"
+	EventCollaboratorAdded = "collaborator_added"
+	EventSavedSearchMatch  = "saved_search_match"
+	EventRewardRedeemed    = "reward_redeemed"
+)
+
+// Delivery channels. Email and digest also keep the in-app notification; none drops the event entirely.
+const (
+	ChannelInApp  = "in_app"
+	ChannelEmail  = "email"
+	ChannelDigest = "digest"
+	ChannelNone   = "none"
+)
+
+// defaultChannels is used for event types a user has not configured
+var defaultChannels = map[string]string{
+	EventApplicationStatus: ChannelEmail,
+	EventNewApplication:    ChannelInApp,
+	EventNewReport:         ChannelInApp,
+	EventReportReview:      ChannelEmail,
+	EventReportComment:     ChannelInApp,
+	EventReportReminder:    ChannelEmail,
+	EventOverdueReports:    ChannelEmail,
+	EventCoinsAwarded:      ChannelInApp,
+	EventCollaboratorAdded: ChannelEmail,
+	EventSavedSearchMatch:  ChannelInApp,
+	EventRewardRedeemed:    ChannelInApp,
+}
+
+// NotificationPreference overrides the delivery channel of one event type for one user
+type NotificationPreference struct {
+	gorm.Model
+	RecipientID   uint   `json:"recipient_id" gorm:"not null;uniqueIndex:idx_notification_preference"`
+	RecipientRole string `json:"recipient_role" gorm:"not null;uniqueIndex:idx_notification_preference"`
+	EventType     string `json:"event_type" gorm:"not null;uniqueIndex:idx_notification_preference"`
+	Channel       string `json:"channel" gorm:"type:TEXT CHECK(channel IN ('in_app','email','digest','none'));not null"`
+}
+
+// GetNotificationPreferences returns the effective channel for every event type, including defaults
+func GetNotificationPreferences(db *gorm.DB, recipientID uint, recipientRole string) (map[string]string, error) {
+	var stored []NotificationPreference
+	if err := db.Where("recipient_id = ? AND recipient_role = ?", recipientID, recipientRole).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[string]string, len(defaultChannels))
+	for eventType, channel := range defaultChannels {
+		preferences[eventType] = channel
+	}
+	for _, pref := range stored {
+		preferences[pref.EventType] = pref.Channel
+	}
+	return preferences, nil
+}
+
+// UpdateNotificationPreferences stores channels for the given event types, leaving others unchanged
+func UpdateNotificationPreferences(db *gorm.DB, recipientID uint, recipientRole string, channels map[string]string) (map[string]string, error) {
+	for eventType, channel := range channels {
+		if _, ok := defaultChannels[eventType]; !ok {
+			return nil, fmt.Errorf("%w: unknown event type %q", gorm.ErrInvalidValue, eventType)
+		}
+		if channel != ChannelInApp && channel != ChannelEmail && channel != ChannelDigest && channel != ChannelNone {
+			return nil, fmt.Errorf("%w: channel must be in_app, email, digest or none", gorm.ErrInvalidValue)
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for eventType, channel := range channels {
+			pref := NotificationPreference{RecipientID: recipientID, RecipientRole: recipientRole, EventType: eventType, Channel: channel}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "recipient_id"}, {Name: "recipient_role"}, {Name: "event_type"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"channel": channel, "updated_at": gorm.Expr("NOW()")}),
+			}).Create(&pref).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetNotificationPreferences(db, recipientID, recipientRole)
+}
+
+// UnsubscribeDigest is the unsubscribe category of digest emails
+const UnsubscribeDigest = "digest"
+
+// UnsubscribeFromEmail stops one category of email for a recipient, keeping the in-app notifications.
+// For an event type its channel becomes in-app, and for saved search matches the searches' email alerts
+// are turned off too; for digests every event type batched into the digest becomes in-app.
+func UnsubscribeFromEmail(db *gorm.DB, recipientID uint, recipientRole, category string) error {
+	preferences, err := GetNotificationPreferences(db, recipientID, recipientRole)
+	if err != nil {
+		return err
+	}
+
+	channels := map[string]string{}
+	switch {
+	case category == UnsubscribeDigest:
+		for eventType, channel := range preferences {
+			if channel == ChannelDigest {
+				channels[eventType] = ChannelInApp
+			}
+		}
+	case preferences[category] == ChannelNone:
+		// already receives nothing
+	case preferences[category] != "":
+		channels[category] = ChannelInApp
+	default:
+		return fmt.Errorf("%w: unknown email category %q", gorm.ErrInvalidValue, category)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if _, err := UpdateNotificationPreferences(tx, recipientID, recipientRole, channels); err != nil {
+			return err
+		}
+		if category == EventSavedSearchMatch && recipientRole == "student" {
+			return tx.Model(&SavedSearch{}).Where("student_id = ?", recipientID).Update("email_alerts", false).Error
+		}
+		return nil
+	})
+}
+
+// notificationChannel returns the channel a recipient chose for an event type
+func notificationChannel(db *gorm.DB, recipientID uint, recipientRole, eventType string) (string, error) {
+	var pref NotificationPreference
+	err := db.Where("recipient_id = ? AND recipient_role = ? AND event_type = ?", recipientID, recipientRole, eventType).First(&pref).Error
+	if err == nil {
+		return pref.Channel, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	if channel, ok := defaultChannels[eventType]; ok {
+		return channel, nil
+	}
+	return ChannelInApp, nil
+}
+
+// NotificationEvent is something a user may need to hear about
+type NotificationEvent struct {
+	Type          string
+	RecipientID   uint
+	RecipientRole string
+	Title         string
+	Message       string
+	Level         string // info, success, warning or error
+	// Email asks for an email even if the recipient's channel is in-app, e.g. a saved search with alerts on.
+	// A channel of none still wins.
+	Email bool
+	// Email template to use instead of the generic notification email, with its data
+	Template     string
+	TemplateData any
+}
+
+// Dispatch delivers an event according to the recipient's preference for its type:
+// an in-app notification, plus an immediate email or a place in the next digest.
+// The notification and its email are saved together; pass a transaction to tie them to the change that caused them.
+func Dispatch(db *gorm.DB, event NotificationEvent) error {
+	channel, err := notificationChannel(db, event.RecipientID, event.RecipientRole, event.Type)
+	if err != nil {
+		return err
+	}
+	if channel == ChannelNone {
+		return nil
+	}
+
+	notification := &Notification{
+		RecipientID:   event.RecipientID,
+		RecipientRole: event.RecipientRole,
+		Title:         event.Title,
+		Message:       event.Message,
+		Type:          event.Level,
+		EventType:     event.Type,
+		Digest:        channel == ChannelDigest && !event.Email,
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := saveNotification(tx, notification); err != nil {
+			return err
+		}
+		if channel != ChannelEmail && !event.Email {
+			return nil
+		}
+		return enqueueEventEmail(tx, event)
+	})
+}
+
+// enqueueEventEmail queues an event's email, with its own template if it has one
+func enqueueEventEmail(tx *gorm.DB, event NotificationEvent) error {
+	name, email, locale, err := recipientContact(tx, event.RecipientID, event.RecipientRole)
+	if err != nil {
+		return err
+	}
+
+	template, data := event.Template, event.TemplateData
+	if template == "" {
+		template, data = "notification", mail_service.NotificationData{Title: event.Title, Message: event.Message}
+	}
+	unsubscribe := &mail_service.Unsubscribe{RecipientID: event.RecipientID, RecipientRole: event.RecipientRole, Category: event.Type}
+	return EnqueueEmail(tx, email, name, template, locale, data, unsubscribe)
+}
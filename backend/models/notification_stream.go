@@ -0,0 +1,76 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/OmarDardery/solve-the-x-backend/realtime"
+	"gorm.io/gorm"
+)
+
+// NotificationChannel is the Postgres NOTIFY channel announcing new notification IDs to every replica
+const NotificationChannel = "notifications"
+
+// NotificationTopic is the hub topic carrying one recipient's notifications
+func NotificationTopic(recipientRole string, recipientID uint) string {
+	return fmt.Sprintf("notifications:%s:%d", recipientRole, recipientID)
+}
+
+// announceNotification tells every replica about a new notification. NOTIFY is transactional,
+// so if the insert is rolled back nobody hears about it.
+func announceNotification(db *gorm.DB, notification *Notification) error {
+	return db.Exec("SELECT pg_notify(?, ?)", NotificationChannel, strconv.FormatUint(uint64(notification.ID), 10)).Error
+}
+
+// StreamNotifications listens for announced notifications and publishes them to the local hub.
+// Each replica runs one listener, so subscribers receive notifications created anywhere.
+func StreamNotifications(ctx context.Context, db *gorm.DB, dsn string, hub *realtime.Hub) {
+	realtime.Listen(ctx, dsn, NotificationChannel, func(payload string) {
+		id, err := strconv.ParseUint(payload, 10, 64)
+		if err != nil {
+			return
+		}
+
+		var notification Notification
+		if err := db.First(&notification, id).Error; err != nil {
+			log.Printf("notification stream: %v", err)
+			return
+		}
+		data, err := json.Marshal(notification)
+		if err != nil {
+			return
+		}
+		hub.Publish(NotificationTopic(notification.RecipientRole, notification.RecipientID), realtime.Event{
+			ID:   notification.ID,
+			Name: "notification",
+			Data: data,
+		})
+	})
+}
+
+// GetNotificationsAfter returns a recipient's notifications newer than afterID, oldest first,
+// so a reconnecting client can catch up on what it missed
+func GetNotificationsAfter(db *gorm.DB, recipientID uint, recipientRole string, afterID uint, limit int) ([]Notification, error) {
+	var notifications []Notification
+	err := db.
+		Where("recipient_id = ? AND recipient_role = ? AND id > ?", recipientID, recipientRole, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&notifications).
+		Error
+	return notifications, err
+}
+
+// GetLatestNotificationID returns the ID of a recipient's newest notification, or 0 if they have none
+func GetLatestNotificationID(db *gorm.DB, recipientID uint, recipientRole string) (uint, error) {
+	var id uint
+	err := db.Model(&Notification{}).
+		Where("recipient_id = ? AND recipient_role = ?", recipientID, recipientRole).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).
+		Error
+	return id, err
+}
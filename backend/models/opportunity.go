@@ -2,61 +2,129 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 // Opportunity represents a research/project/internship post.
+// It is owned by either a professor or an organization, never both.
 type Opportunity struct {
 	gorm.Model
-	ProfessorID  uint       `json:"professor_id" gorm:"not null"`
-	Professor    *Professor `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ProfessorID;references:ID"`
-	Name         string     `json:"name" gorm:"not null"`
-	Details      string     `json:"details" gorm:"type:text"`
-	Requirements string     `json:"requirements" gorm:"type:text"`
-	Reward       string     `json:"reward" gorm:"type:text"`
+	ProfessorID    *uint         `json:"professor_id"`
+	Professor      *Professor    `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ProfessorID;references:ID"`
+	OrganizationID *uint         `json:"organization_id" gorm:"index;check:chk_opportunities_owner,(professor_id IS NULL) <> (organization_id IS NULL)"`
+	Organization   *Organization `json:"organization,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:OrganizationID;references:ID"`
+	Name           string        `json:"name" gorm:"not null"`
+	Details        string        `json:"details" gorm:"type:text"`
+	Requirements   string        `json:"requirements" gorm:"type:text"`
+	Reward         string        `json:"reward" gorm:"type:text"`
 	// Relationship: each opportunity can have multiple tags
 	RequirementTags []Tag `json:"requirement_tags" gorm:"many2many:opportunity_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 
 	// Enforce type constraint
 	Type string `json:"type" gorm:"type:TEXT CHECK(type IN ('research','project','internship'));not null"`
+	// Drafts are hidden from public listings; closed opportunities stay visible but no longer count as open
+	Status string `json:"status" gorm:"type:TEXT CHECK(status IN ('draft','open','closed'));not null;default:'open'"`
+	// Set the first time the opportunity becomes open; saved search alerts fire at that moment
+	PublishedAt *time.Time `json:"published_at"`
 }
 
-// CreateOpportunity creates a new opportunity
-func CreateOpportunity(db *gorm.DB, professorID uint, name, details, requirements, reward, opType string, tagIDs []uint) (*Opportunity, error) {
+// OwnerRole returns "professor" or "organization" depending on who posted the opportunity
+func (o Opportunity) OwnerRole() string {
+	if o.OrganizationID != nil {
+		return "organization"
+	}
+	return "professor"
+}
+
+const (
+	OpportunityDraft  = "draft"
+	OpportunityOpen   = "open"
+	OpportunityClosed = "closed"
+)
+
+// validateOpportunityStatus rejects statuses the database CHECK would refuse
+func validateOpportunityStatus(status string) error {
+	if status != OpportunityDraft && status != OpportunityOpen && status != OpportunityClosed {
+		return fmt.Errorf("%w: status must be draft, open or closed", gorm.ErrInvalidValue)
+	}
+	return nil
+}
+
+// CreateOpportunity creates a new opportunity owned by a professor or an organization (ownerRole)
+func CreateOpportunity(db *gorm.DB, ownerRole string, ownerID uint, name, details, requirements, reward, opType, status string, tagIDs []uint) (*Opportunity, error) {
 	// Validate type
 	if opType != "research" && opType != "project" && opType != "internship" {
-		return nil, errors.New("invalid opportunity type")
+		return nil, fmt.Errorf("%w: invalid opportunity type", gorm.ErrInvalidValue)
+	}
+
+	// Validate status
+	if status == "" {
+		status = OpportunityOpen
+	}
+	if err := validateOpportunityStatus(status); err != nil {
+		return nil, err
 	}
 
 	opportunity := Opportunity{
-		ProfessorID:  professorID,
 		Name:         name,
 		Details:      details,
 		Requirements: requirements,
 		Reward:       reward,
 		Type:         opType,
+		Status:       status,
 	}
-
-	// Create the opportunity
-	if err := db.Create(&opportunity).Error; err != nil {
-		return nil, err
+	switch ownerRole {
+	case "professor":
+		opportunity.ProfessorID = &ownerID
+	case "organization":
+		opportunity.OrganizationID = &ownerID
+	default:
+		return nil, errors.New("invalid opportunity owner")
+	}
+	if status == OpportunityOpen {
+		now := time.Now()
+		opportunity.PublishedAt = &now
 	}
 
-	// Associate tags if provided
-	if len(tagIDs) > 0 {
-		var tags []Tag
-		if err := db.Find(&tags, tagIDs).Error; err != nil {
-			return nil, err
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Create the opportunity
+		if err := tx.Create(&opportunity).Error; err != nil {
+			return err
 		}
-		if err := db.Model(&opportunity).Association("RequirementTags").Append(&tags); err != nil {
-			return nil, err
+
+		// A professor creator owns the opportunity as its first collaborator
+		if opportunity.ProfessorID != nil {
+			owner := OpportunityCollaborator{
+				OpportunityID: opportunity.ID,
+				ProfessorID:   ownerID,
+				Role:          CollaboratorOwner,
+			}
+			if err := tx.Create(&owner).Error; err != nil {
+				return err
+			}
 		}
+
+		// Associate tags if provided
+		if len(tagIDs) > 0 {
+			var tags []Tag
+			if err := tx.Find(&tags, tagIDs).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&opportunity).Association("RequirementTags").Append(&tags); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Reload with associations
-	if err := db.Preload("Professor").Preload("RequirementTags").First(&opportunity, opportunity.ID).Error; err != nil {
+	if err := db.Preload("Professor").Preload("Organization").Preload("RequirementTags").First(&opportunity, opportunity.ID).Error; err != nil {
 		return nil, err
 	}
 
@@ -65,7 +133,7 @@ func CreateOpportunity(db *gorm.DB, professorID uint, name, details, requirement
 
 func GetOpportunityByID(db *gorm.DB, id uint) (*Opportunity, error) {
 	var opportunity Opportunity
-	if err := db.Preload("Professor").Preload("RequirementTags").First(&opportunity, id).Error; err != nil {
+	if err := db.Preload("Professor").Preload("Organization").Preload("RequirementTags").First(&opportunity, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("opportunity not found")
 		}
@@ -74,22 +142,65 @@ func GetOpportunityByID(db *gorm.DB, id uint) (*Opportunity, error) {
 	return &opportunity, nil
 }
 
-// UpdateOpportunity updates an opportunity’s fields
+// UpdateOpportunity updates an opportunity’s fields and replaces its requirement tags if "tag_ids" is provided
 func UpdateOpportunity(db *gorm.DB, id uint, updates map[string]interface{}) (*Opportunity, error) {
 	opportunity, err := GetOpportunityByID(db, id)
 	if err != nil {
 		return nil, err
 	}
 
+	// Ownership and publication are managed by the server, not the request body
+	delete(updates, "professor_id")
+	delete(updates, "organization_id")
+	delete(updates, "published_at")
+
+	if raw, ok := updates["status"]; ok {
+		status, _ := raw.(string)
+		if err := validateOpportunityStatus(status); err != nil {
+			return nil, err
+		}
+	}
+
+	// Pull tag IDs out of the column updates
+	var tagIDs []uint
+	rawTagIDs, replaceTags := updates["tag_ids"]
+	if replaceTags {
+		delete(updates, "tag_ids")
+		var ok bool
+		if tagIDs, ok = uintSlice(rawTagIDs); !ok {
+			return nil, fmt.Errorf("%w: tag_ids must be an array of tag IDs", gorm.ErrInvalidValue)
+		}
+	}
+
 	// Automatically update the "updated_at" timestamp
 	updates["updated_at"] = time.Now()
 
-	if err := db.Model(opportunity).Updates(updates).Error; err != nil {
+	// Record the first publication
+	if status, ok := updates["status"].(string); ok && status == OpportunityOpen && opportunity.PublishedAt == nil {
+		updates["published_at"] = updates["updated_at"]
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(opportunity).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if !replaceTags {
+			return nil
+		}
+
+		tags, err := findTagsByIDs(tx, tagIDs)
+		if err != nil {
+			return err
+		}
+		return tx.Model(opportunity).Association("RequirementTags").Replace(tags)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Refresh record with relations
-	if err := db.Preload("Professor").Preload("RequirementTags").First(opportunity, id).Error; err != nil {
+	if err := db.Preload("Professor").Preload("Organization").Preload("RequirementTags").First(opportunity, id).Error; err != nil {
 		return nil, err
 	}
 
@@ -110,11 +221,13 @@ func DeleteOpportunity(db *gorm.DB, id uint) error {
 	return nil
 }
 
-// GetOpportunitiesByProfessorID returns all opportunities created by a given professor
+// GetOpportunitiesByProfessorID returns all opportunities a professor collaborates on (in any role)
 func GetOpportunitiesByProfessorID(db *gorm.DB, professorID uint) ([]Opportunity, error) {
 	var opportunities []Opportunity
-	if err := db.Preload("Professor").Preload("RequirementTags").
-		Where("professor_id = ?", professorID).
+	if err := db.Preload("Professor").Preload("Organization").Preload("RequirementTags").
+		Joins("JOIN opportunity_collaborators ON opportunity_collaborators.opportunity_id = opportunities.id AND opportunity_collaborators.deleted_at IS NULL").
+		Where("opportunity_collaborators.professor_id = ?", professorID).
+		Order("opportunities.created_at DESC").
 		Find(&opportunities).Error; err != nil {
 		return nil, err
 	}
@@ -123,10 +236,26 @@ func GetOpportunitiesByProfessorID(db *gorm.DB, professorID uint) ([]Opportunity
 	return opportunities, nil
 }
 
-// GetAllOpportunities returns all opportunities
+// GetOpportunitiesByOrganizationID returns all opportunities posted by an organization
+func GetOpportunitiesByOrganizationID(db *gorm.DB, organizationID uint, includeDrafts bool) ([]Opportunity, error) {
+	var opportunities []Opportunity
+	query := db.Preload("Organization").Preload("RequirementTags").
+		Where("organization_id = ?", organizationID)
+	if !includeDrafts {
+		query = query.Where("status <> ?", OpportunityDraft)
+	}
+	if err := query.Order("created_at DESC").Find(&opportunities).Error; err != nil {
+		return nil, err
+	}
+
+	return opportunities, nil
+}
+
+// GetAllOpportunities returns all published (non-draft) opportunities
 func GetAllOpportunities(db *gorm.DB) ([]Opportunity, error) {
 	var opportunities []Opportunity
-	if err := db.Preload("Professor").Preload("RequirementTags").
+	if err := db.Preload("Professor").Preload("Organization").Preload("RequirementTags").
+		Where("status <> ?", OpportunityDraft).
 		Order("created_at DESC").
 		Find(&opportunities).Error; err != nil {
 		return nil, err
@@ -134,3 +263,11 @@ func GetAllOpportunities(db *gorm.DB) ([]Opportunity, error) {
 
 	return opportunities, nil
 }
+
+// BackfillPublishedAt marks opportunities published before PublishedAt existed so they don't trigger alerts again
+func BackfillPublishedAt(db *gorm.DB) error {
+	return db.Model(&Opportunity{}).
+		Where("published_at IS NULL AND status <> ?", OpportunityDraft).
+		Update("published_at", gorm.Expr("created_at")).
+		Error
+}
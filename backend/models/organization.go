@@ -5,7 +5,6 @@ import (
 	"time"
 
 	jwt_service "github.com/OmarDardery/solve-the-x-backend/jwt_service"
-	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
 	"gorm.io/gorm"
 )
 
@@ -17,6 +16,8 @@ type Organization struct {
 	Contact             string    `json:"contact"` // Phone, email, or other contact info
 	Link                string    `json:"link"`    // Website, LinkedIn, Instagram, etc.
 	LastChangedPassword time.Time `json:"last_changed_password"`
+	IsAdmin             bool      `json:"is_admin" gorm:"not null;default:false"`      // set from ADMIN_EMAILS at startup
+	Locale              string    `json:"locale" gorm:"size:10;not null;default:'en'"` // language of the emails they receive
 }
 
 // Generate JWT for the organization
@@ -45,7 +46,7 @@ func AuthenticateOrganization(db *gorm.DB, email, password string) (*Organizatio
 }
 
 // CreateOrganization registers a new organization with hashed password
-func CreateOrganization(db *gorm.DB, name, email, password, contact, link string) error {
+func CreateOrganization(db *gorm.DB, name, email, password, contact, link, locale string) error {
 	var existing Organization
 	if err := db.Where("email = ?", email).First(&existing).Error; err == nil {
 		return errors.New("email already registered")
@@ -65,18 +66,12 @@ func CreateOrganization(db *gorm.DB, name, email, password, contact, link string
 		Contact:             contact,
 		Link:                link,
 		LastChangedPassword: time.Now(),
+		Locale:              locale,
 	}
 
 	return db.Create(organization).Error
 }
 
-func (o Organization) Notify(mailman *mail_service.Mailman, subject, content string) error {
-	if mailman == nil {
-		return mail_service.SendNotification(o.Email, subject, content)
-	}
-	return mailman.SendNotification(o.Email, subject, content)
-}
-
 // GetOrganizationByID retrieves an organization by ID
 func GetOrganizationByID(db *gorm.DB, id uint) (*Organization, error) {
 	var organization Organization
@@ -108,6 +103,27 @@ func UpdateOrganization(db *gorm.DB, id uint, updates map[string]interface{}) (*
 		return nil, err
 	}
 
+	updates, err = editableUpdates(updates, organizationEditable)
+	if err != nil {
+		return nil, err
+	}
+
+	// Hash a new password like students and professors do
+	if newPasswordRaw, ok := updates["Password"]; ok {
+		newPassword, ok := newPasswordRaw.(string)
+		if !ok {
+			return nil, errors.New("password must be a string")
+		}
+
+		hashed, err := HashPassword(newPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		updates["Password"] = hashed
+		updates["LastChangedPassword"] = time.Now()
+	}
+
 	updates["updated_at"] = time.Now()
 
 	if err := db.Model(organization).Updates(updates).Error; err != nil {
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	jwt_service "github.com/OmarDardery/solve-the-x-backend/jwt_service"
-	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
 	"gorm.io/gorm"
 )
 
@@ -16,6 +15,8 @@ type Professor struct {
 	Email               string    `json:"email" gorm:"unique"`
 	Password            string    `json:"password"`
 	LastChangedPassword time.Time `json:"last_changed_password"`
+	IsAdmin             bool      `json:"is_admin" gorm:"not null;default:false"`      // set from ADMIN_EMAILS at startup
+	Locale              string    `json:"locale" gorm:"size:10;not null;default:'en'"` // language of the emails they receive
 }
 
 // Generate JWT for the professor
@@ -44,7 +45,7 @@ func AuthenticateProfessor(db *gorm.DB, email, password string) (*Professor, err
 }
 
 // CreateProfessor registers a new professor with hashed password
-func CreateProfessor(db *gorm.DB, firstName, lastName, email, password string) error {
+func CreateProfessor(db *gorm.DB, firstName, lastName, email, password, locale string) error {
 	var existing Professor
 	if err := db.Where("email = ?", email).First(&existing).Error; err == nil {
 		return errors.New("email already registered")
@@ -63,19 +64,12 @@ func CreateProfessor(db *gorm.DB, firstName, lastName, email, password string) e
 		Email:               email,
 		Password:            passwordHash,
 		LastChangedPassword: time.Now(),
+		Locale:              locale,
 	}
 
 	return db.Create(professor).Error
 }
 
-func (p Professor) Notify(mailman *mail_service.Mailman, subject, content string) error {
-	if mailman == nil {
-		// Fallback to legacy function for backward compatibility
-		return mail_service.SendNotification(p.Email, subject, content)
-	}
-	return mailman.SendNotification(p.Email, subject, content)
-}
-
 // GetProfessorByID retrieves a professor by ID
 func GetProfessorByID(db *gorm.DB, id uint) (*Professor, error) {
 	var professor Professor
@@ -95,6 +89,11 @@ func UpdateProfessor(db *gorm.DB, id uint, updates map[string]interface{}) (*Pro
 		return nil, err
 	}
 
+	updates, err = editableUpdates(updates, professorEditable)
+	if err != nil {
+		return nil, err
+	}
+
 	// Handle password update
 	if newPasswordRaw, ok := updates["Password"]; ok {
 		newPassword, ok := newPasswordRaw.(string)
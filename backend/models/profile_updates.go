@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+
+	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
+	"gorm.io/gorm"
+)
+
+// Fields users may change on their own profile, by the JSON or Go name clients send, mapped to the
+// update key. Email and admin status are deliberately absent.
+var (
+	studentEditable = map[string]string{
+		"first_name": "first_name", "FirstName": "first_name",
+		"last_name": "last_name", "LastName": "last_name",
+		"password": "Password", "Password": "Password",
+		"locale": "locale", "Locale": "locale",
+	}
+	professorEditable    = studentEditable
+	organizationEditable = map[string]string{
+		"name": "name", "Name": "name",
+		"contact": "contact", "Contact": "contact",
+		"link": "link", "Link": "link",
+		"password": "Password", "Password": "Password",
+		"locale": "locale", "Locale": "locale",
+	}
+)
+
+// editableUpdates keeps the whitelisted fields of a profile update, rejecting any other field
+func editableUpdates(updates map[string]interface{}, editable map[string]string) (map[string]interface{}, error) {
+	allowed := make(map[string]interface{}, len(updates))
+	for key, value := range updates {
+		column, ok := editable[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s cannot be changed", gorm.ErrInvalidValue, key)
+		}
+		if locale, isString := value.(string); column == "locale" && (!isString || !slices.Contains(mail_service.Locales, locale)) {
+			return nil, fmt.Errorf("%w: locale must be one of %v", gorm.ErrInvalidValue, mail_service.Locales)
+		}
+		allowed[column] = value
+	}
+	return allowed, nil
+}
@@ -3,24 +3,125 @@ package models
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
-// WeeklyReport represents a student's weekly progress report (simplified - just a link)
+// WeeklyReport is a student's progress report to a professor for one ISO week
 type WeeklyReport struct {
 	gorm.Model
-	StudentID   uint     `json:"student_id" gorm:"not null"`
-	Student     *Student `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:StudentID;references:ID"`
-	RecipientID uint     `json:"recipient_id" gorm:"not null"`
-	DriveLink   string   `json:"drive_link" gorm:"not null"`
+	StudentID       uint               `json:"student_id" gorm:"not null"`
+	Student         *Student           `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:StudentID;references:ID"`
+	RecipientID     uint               `json:"recipient_id" gorm:"not null"`
+	Year            int                `json:"year" gorm:"not null;default:0"` // ISO week-numbering year
+	Week            int                `json:"week" gorm:"not null;default:0"` // ISO week, 1-53
+	OpportunityID   *uint              `json:"opportunity_id" gorm:"index"`
+	Opportunity     *Opportunity       `json:"opportunity,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:OpportunityID;references:ID"`
+	Accomplishments string             `json:"accomplishments" gorm:"type:text"`
+	Blockers        string             `json:"blockers" gorm:"type:text"`
+	NextSteps       string             `json:"next_steps" gorm:"type:text"`
+	HoursSpent      float64            `json:"hours_spent"`
+	Attachments     []ReportAttachment `json:"attachments" gorm:"foreignKey:ReportID"`
+	Status          string             `json:"status" gorm:"type:TEXT CHECK(status IN ('submitted','approved','needs_revision'));not null;default:'submitted'"`
+	ReviewedAt      *time.Time         `json:"reviewed_at"`
+	// Reports from before structured sections were only a link; new reports use Attachments instead
+	DriveLink string `json:"drive_link,omitempty"`
 }
 
+// ReportAttachment is a link to a supporting document (Drive file, repository, slides...)
+type ReportAttachment struct {
+	gorm.Model
+	ReportID uint          `json:"report_id" gorm:"not null;index"`
+	Report   *WeeklyReport `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ReportID;references:ID"`
+	Name     string        `json:"name"`
+	URL      string        `json:"url" gorm:"not null"`
+}
+
+const (
+	ReportSubmitted     = "submitted"
+	ReportApproved      = "approved"
+	ReportNeedsRevision = "needs_revision"
+)
+
+// ReportContent holds the sections of a report a student can write and later revise
+type ReportContent struct {
+	OpportunityID   *uint   `json:"opportunity_id"`
+	Accomplishments string  `json:"accomplishments" binding:"required"`
+	Blockers        string  `json:"blockers"`
+	NextSteps       string  `json:"next_steps"`
+	HoursSpent      float64 `json:"hours_spent"`
+	Attachments     []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"attachments"`
+}
+
+func (in ReportContent) validate() error {
+	if strings.TrimSpace(in.Accomplishments) == "" {
+		return fmt.Errorf("%w: accomplishments are required", gorm.ErrInvalidValue)
+	}
+	if in.HoursSpent < 0 || in.HoursSpent > 168 {
+		return fmt.Errorf("%w: hours spent must be between 0 and 168", gorm.ErrInvalidValue)
+	}
+	for _, attachment := range in.Attachments {
+		u, err := url.ParseRequestURI(attachment.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("%w: attachment url must be an http(s) link", gorm.ErrInvalidValue)
+		}
+	}
+	return nil
+}
+
+func (in ReportContent) attachments() []ReportAttachment {
+	attachments := []ReportAttachment{}
+	for _, attachment := range in.Attachments {
+		attachments = append(attachments, ReportAttachment{Name: attachment.Name, URL: attachment.URL})
+	}
+	return attachments
+}
+
+// ReportInput holds what a student submits for a weekly report. Year and Week default to the current ISO week.
+type ReportInput struct {
+	RecipientID uint `json:"recipient_id" binding:"required"`
+	Year        int  `json:"year"`
+	Week        int  `json:"week"`
+	ReportContent
+}
+
+func (in *ReportInput) validate(now time.Time) error {
+	if in.Year == 0 && in.Week == 0 {
+		in.Year, in.Week = now.ISOWeek()
+	}
+	// December 28 always falls in the last ISO week of its year
+	_, weeksInYear := time.Date(in.Year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	if in.Week < 1 || in.Week > weeksInYear {
+		return fmt.Errorf("%w: week must be between 1 and %d for %d", gorm.ErrInvalidValue, weeksInYear, in.Year)
+	}
+	currentYear, currentWeek := now.ISOWeek()
+	if in.Year > currentYear || (in.Year == currentYear && in.Week > currentWeek) {
+		return fmt.Errorf("%w: cannot report on a future week", gorm.ErrInvalidValue)
+	}
+	return in.ReportContent.validate()
+}
+
+// ErrNotSupervisor is returned when a student reports to a professor who has not accepted them
+var ErrNotSupervisor = errors.New("you can only report to a professor who accepted your application")
+
+// ErrDuplicateReport is returned when a student already reported to the recipient for that week
+var ErrDuplicateReport = errors.New("you already submitted a report to this professor for that week")
+
 // CreateReport creates a new weekly report and notifies the professor
-func CreateReport(db *gorm.DB, studentID, recipientID uint, driveLink string) (*WeeklyReport, error) {
+func CreateReport(db *gorm.DB, studentID uint, input ReportInput) (*WeeklyReport, error) {
+	if err := input.validate(time.Now()); err != nil {
+		return nil, err
+	}
+
 	// Validate recipient exists
 	var prof Professor
-	if err := db.First(&prof, recipientID).Error; err != nil {
+	if err := db.First(&prof, input.RecipientID).Error; err != nil {
 		return nil, errors.New("professor not found")
 	}
 
@@ -30,24 +131,53 @@ func CreateReport(db *gorm.DB, studentID, recipientID uint, driveLink string) (*
 		return nil, errors.New("student not found")
 	}
 
+	// Students report only to professors supervising them, and only about opportunities they were accepted into
+	supervised, err := IsSupervisor(db, input.RecipientID, studentID, input.OpportunityID)
+	if err != nil {
+		return nil, err
+	}
+	if !supervised {
+		return nil, ErrNotSupervisor
+	}
+
 	report := &WeeklyReport{
-		StudentID:   studentID,
-		RecipientID: recipientID,
-		DriveLink:   driveLink,
+		StudentID:       studentID,
+		RecipientID:     input.RecipientID,
+		Year:            input.Year,
+		Week:            input.Week,
+		OpportunityID:   input.OpportunityID,
+		Accomplishments: strings.TrimSpace(input.Accomplishments),
+		Blockers:        strings.TrimSpace(input.Blockers),
+		NextSteps:       strings.TrimSpace(input.NextSteps),
+		HoursSpent:      input.HoursSpent,
+		Attachments:     input.attachments(),
 	}
 
-	if err := db.Create(report).Error; err != nil {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// The partial unique index backs this check up under concurrency
+		var existing int64
+		if err := tx.Model(&WeeklyReport{}).
+			Where("student_id = ? AND recipient_id = ? AND year = ? AND week = ?", studentID, input.RecipientID, input.Year, input.Week).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrDuplicateReport
+		}
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+
+		// Notify professor about new report
+		studentName := fmt.Sprintf("%s %s", student.FirstName, student.LastName)
+		return NotifyNewReport(tx, input.RecipientID, studentName, input.Year, input.Week)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	// Preload relationships
-	db.Preload("Student").First(report, report.ID)
-
-	// Notify professor about new report
-	studentName := fmt.Sprintf("%s %s", student.FirstName, student.LastName)
-	NotifyNewReport(db, recipientID, studentName)
-
-	return report, nil
+	return GetReportByID(db, report.ID)
 }
 
 // GetReportsByStudentID returns all reports submitted by a student
@@ -55,8 +185,10 @@ func GetReportsByStudentID(db *gorm.DB, studentID uint) ([]WeeklyReport, error)
 	var reports []WeeklyReport
 	err := db.
 		Preload("Student").
+		Preload("Opportunity").
+		Preload("Attachments").
 		Where("student_id = ?", studentID).
-		Order("created_at DESC").
+		Order("year DESC, week DESC, created_at DESC").
 		Find(&reports).
 		Error
 	return reports, err
@@ -67,8 +199,10 @@ func GetReportsByRecipientID(db *gorm.DB, recipientID uint) ([]WeeklyReport, err
 	var reports []WeeklyReport
 	err := db.
 		Preload("Student").
+		Preload("Opportunity").
+		Preload("Attachments").
 		Where("recipient_id = ?", recipientID).
-		Order("created_at DESC").
+		Order("year DESC, week DESC, created_at DESC").
 		Find(&reports).
 		Error
 	return reports, err
@@ -77,7 +211,7 @@ func GetReportsByRecipientID(db *gorm.DB, recipientID uint) ([]WeeklyReport, err
 // GetReportByID retrieves a report by ID
 func GetReportByID(db *gorm.DB, id uint) (*WeeklyReport, error) {
 	var report WeeklyReport
-	err := db.Preload("Student").First(&report, id).Error
+	err := db.Preload("Student").Preload("Opportunity").Preload("Attachments").First(&report, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("report not found")
@@ -98,3 +232,28 @@ func DeleteReport(db *gorm.DB, reportID, studentID uint) error {
 	}
 	return nil
 }
+
+// BackfillReportWeeks dates link-only reports by the ISO week they were submitted in, turns their Drive link
+// into an attachment, and then enforces one report per student, recipient and week.
+// Legacy reports keep their drive_link and are left out of the uniqueness rule, since older data may repeat a week.
+func BackfillReportWeeks(db *gorm.DB) error {
+	statements := []string{
+		`UPDATE weekly_reports
+			SET year = EXTRACT(ISOYEAR FROM created_at), week = EXTRACT(WEEK FROM created_at)
+			WHERE year = 0`,
+		`INSERT INTO report_attachments (created_at, updated_at, report_id, name, url)
+			SELECT r.created_at, r.created_at, r.id, 'Report document', r.drive_link
+			FROM weekly_reports r
+			WHERE COALESCE(r.drive_link, '') <> ''
+			AND NOT EXISTS (SELECT 1 FROM report_attachments a WHERE a.report_id = r.id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_weekly_reports_student_recipient_week
+			ON weekly_reports (student_id, recipient_id, year, week)
+			WHERE deleted_at IS NULL AND COALESCE(drive_link, '') = ''`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
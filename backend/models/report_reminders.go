@@ -0,0 +1,122 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MissingReport is an accepted student who has not reported on an opportunity for a week
+type MissingReport struct {
+	StudentID       uint
+	FirstName       string
+	LastName        string
+	Email           string
+	OpportunityID   uint
+	OpportunityName string
+}
+
+// GetMissingReports finds students accepted on a professor-run opportunity before the week ended who have
+// not sent that week's report to any of its collaborators
+func GetMissingReports(db *gorm.DB, year, week int) ([]MissingReport, error) {
+	weekEnd := isoWeekStart(year, week).AddDate(0, 0, 7)
+
+	missing := []MissingReport{}
+	err := db.Raw(`
+		SELECT a.student_id, s.first_name, s.last_name, s.email, a.opportunity_id, o.name AS opportunity_name
+		FROM applications a
+		JOIN students s ON s.id = a.student_id AND s.deleted_at IS NULL
+		JOIN opportunities o ON o.id = a.opportunity_id AND o.deleted_at IS NULL AND o.professor_id IS NOT NULL
+		WHERE a.status = @accepted AND a.deleted_at IS NULL AND a.accepted_at < @weekEnd
+		AND NOT EXISTS (
+			SELECT 1 FROM weekly_reports r
+			WHERE r.student_id = a.student_id AND r.deleted_at IS NULL AND r.year = @year AND r.week = @week
+			AND (r.opportunity_id = a.opportunity_id OR r.recipient_id IN (
+				SELECT oc.professor_id FROM opportunity_collaborators oc
+				WHERE oc.opportunity_id = a.opportunity_id AND oc.deleted_at IS NULL AND oc.role IN ('owner','editor')))
+		)
+		ORDER BY o.name, s.last_name, s.first_name`,
+		map[string]interface{}{
+			"accepted": StatusAccepted,
+			"weekEnd":  weekEnd,
+			"year":     year,
+			"week":     week,
+		},
+	).Scan(&missing).Error
+	return missing, err
+}
+
+// SendMissingReportReminders reminds each student who still owes a report for the week
+func SendMissingReportReminders(db *gorm.DB, year, week int) error {
+	missing, err := GetMissingReports(db, year, week)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range missing {
+		title := "⏰ Weekly Report Reminder"
+		message := fmt.Sprintf("Don't forget to submit your weekly report for '%s' (week %d, %d)", m.OpportunityName, week, year)
+		if err := Dispatch(db, NotificationEvent{
+			Type:          EventReportReminder,
+			RecipientID:   m.StudentID,
+			RecipientRole: "student",
+			Title:         title,
+			Message:       message,
+			Level:         "warning",
+		}); err != nil {
+			log.Printf("report reminder for student %d: %v", m.StudentID, err)
+		}
+	}
+	return nil
+}
+
+// SendOverdueReportDigests sends each owner and editor of an opportunity one summary of the students
+// who did not report for the week
+func SendOverdueReportDigests(db *gorm.DB, year, week int) error {
+	missing, err := GetMissingReports(db, year, week)
+	if err != nil {
+		return err
+	}
+
+	lines := map[uint][]string{}
+	var order []uint
+	for _, m := range missing {
+		professorIDs, err := getCollaboratorIDs(db, m.OpportunityID, CollaboratorEditor)
+		if err != nil {
+			return err
+		}
+		for _, professorID := range professorIDs {
+			if _, ok := lines[professorID]; !ok {
+				order = append(order, professorID)
+			}
+			lines[professorID] = append(lines[professorID], fmt.Sprintf("%s %s (%s)", m.FirstName, m.LastName, m.OpportunityName))
+		}
+	}
+
+	for _, professorID := range order {
+		title := fmt.Sprintf("📋 %d Overdue Weekly Reports", len(lines[professorID]))
+		message := fmt.Sprintf("These students did not submit a report for week %d, %d: %s", week, year, strings.Join(lines[professorID], ", "))
+		if err := Dispatch(db, NotificationEvent{
+			Type:          EventOverdueReports,
+			RecipientID:   professorID,
+			RecipientRole: "professor",
+			Title:         title,
+			Message:       message,
+			Level:         "warning",
+		}); err != nil {
+			log.Printf("report digest for professor %d: %v", professorID, err)
+		}
+	}
+	return nil
+}
+
+// isoWeekStart returns midnight UTC on the Monday of an ISO week
+func isoWeekStart(year, week int) time.Time {
+	// January 4 is always in week 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, (week-1)*7)
+}
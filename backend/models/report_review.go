@@ -0,0 +1,211 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReportComment is one message in the feedback thread between a student and the professor reviewing their report
+type ReportComment struct {
+	gorm.Model
+	ReportID   uint          `json:"report_id" gorm:"not null;index"`
+	Report     *WeeklyReport `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ReportID;references:ID"`
+	AuthorRole string        `json:"author_role" gorm:"type:TEXT CHECK(author_role IN ('student','professor'));not null"`
+	AuthorID   uint          `json:"author_id" gorm:"not null"`
+	AuthorName string        `json:"author_name" gorm:"-"`
+	Body       string        `json:"body" gorm:"type:text;not null"`
+}
+
+// fillCommentAuthors sets each comment's author display name
+func fillCommentAuthors(db *gorm.DB, comments []ReportComment) error {
+	names := map[string]string{}
+	for i := range comments {
+		key := fmt.Sprintf("%s:%d", comments[i].AuthorRole, comments[i].AuthorID)
+		if _, ok := names[key]; !ok {
+			var author struct{ FirstName, LastName string }
+			table := "students"
+			if comments[i].AuthorRole == "professor" {
+				table = "professors"
+			}
+			if err := db.Table(table).Select("first_name, last_name").Where("id = ?", comments[i].AuthorID).Scan(&author).Error; err != nil {
+				return err
+			}
+			names[key] = strings.TrimSpace(author.FirstName + " " + author.LastName)
+		}
+		comments[i].AuthorName = names[key]
+	}
+	return nil
+}
+
+// GetReportComments returns a report's feedback thread, oldest first
+func GetReportComments(db *gorm.DB, reportID uint) ([]ReportComment, error) {
+	comments := []ReportComment{}
+	if err := db.Where("report_id = ?", reportID).Order("created_at ASC").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	if err := fillCommentAuthors(db, comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// AddReportComment posts to a report's feedback thread and notifies the other participant
+func AddReportComment(db *gorm.DB, report *WeeklyReport, authorRole string, authorID uint, body string) (*ReportComment, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, fmt.Errorf("%w: comment cannot be empty", gorm.ErrInvalidValue)
+	}
+
+	comment := &ReportComment{
+		ReportID:   report.ID,
+		AuthorRole: authorRole,
+		AuthorID:   authorID,
+		Body:       body,
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		created := []ReportComment{*comment}
+		if err := fillCommentAuthors(tx, created); err != nil {
+			return err
+		}
+		comment = &created[0]
+
+		if authorRole == "professor" {
+			return NotifyReportFeedback(tx, report.StudentID, comment.AuthorName, report.Year, report.Week)
+		}
+		return NotifyReportReply(tx, report.RecipientID, comment.AuthorName, report.Year, report.Week)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// ErrReportApproved is returned when changing a report that was already approved
+var ErrReportApproved = errors.New("approved reports cannot be changed")
+
+// ReviewReport sets a report's review status, optionally adding a comment, and notifies the student.
+// Approval is final and awards the report_approved coin reward once per student, professor and week,
+// so deleting and resubmitting an approved report does not pay again.
+func ReviewReport(db *gorm.DB, report *WeeklyReport, professorID uint, status, comment string) (*WeeklyReport, error) {
+	if report.Status == ReportApproved {
+		return nil, ErrReportApproved
+	}
+	if status != ReportApproved && status != ReportNeedsRevision {
+		return nil, fmt.Errorf("%w: status must be approved or needs_revision", gorm.ErrInvalidValue)
+	}
+	if status == ReportNeedsRevision && strings.TrimSpace(comment) == "" {
+		return nil, fmt.Errorf("%w: explain what needs revision in a comment", gorm.ErrInvalidValue)
+	}
+
+	var professor Professor
+	if err := db.First(&professor, professorID).Error; err != nil {
+		return nil, errors.New("professor not found")
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Guard against a concurrent approval landing since the report was loaded
+		result := tx.Model(report).Where("status <> ?", ReportApproved).Updates(map[string]interface{}{"status": status, "reviewed_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrReportApproved
+		}
+		if strings.TrimSpace(comment) != "" {
+			if err := tx.Create(&ReportComment{
+				ReportID:   report.ID,
+				AuthorRole: "professor",
+				AuthorID:   professorID,
+				Body:       strings.TrimSpace(comment),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		professorName := fmt.Sprintf("%s %s", professor.FirstName, professor.LastName)
+		if err := NotifyReportReviewed(tx, report.StudentID, professorName, report.Year, report.Week, status); err != nil {
+			return err
+		}
+
+		// A failed award rolls back on its own and should not block the review
+		if status == ReportApproved {
+			key := fmt.Sprintf("%s:week:%d:%d:%d-%d", RuleReportApproved, report.StudentID, report.RecipientID, report.Year, report.Week)
+			if _, err := awardCoinsOnce(tx, RuleReportApproved, report.StudentID, "report", report.ID, "professor", &professorID, key); err != nil && !errors.Is(err, ErrAlreadyAwarded) {
+				log.Printf("coin award for report %d: %v", report.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetReportByID(db, report.ID)
+}
+
+// ReviseReport replaces a report's content and sends it back for review. Approved reports are final.
+func ReviseReport(db *gorm.DB, report *WeeklyReport, content ReportContent) (*WeeklyReport, error) {
+	if report.Status == ReportApproved {
+		return nil, ErrReportApproved
+	}
+	if err := content.validate(); err != nil {
+		return nil, err
+	}
+	if content.OpportunityID != nil {
+		supervised, err := IsSupervisor(db, report.RecipientID, report.StudentID, content.OpportunityID)
+		if err != nil {
+			return nil, err
+		}
+		if !supervised {
+			return nil, ErrNotSupervisor
+		}
+	}
+
+	wasReturned := report.Status == ReportNeedsRevision
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(report).Updates(map[string]interface{}{
+			"opportunity_id":  content.OpportunityID,
+			"accomplishments": strings.TrimSpace(content.Accomplishments),
+			"blockers":        strings.TrimSpace(content.Blockers),
+			"next_steps":      strings.TrimSpace(content.NextSteps),
+			"hours_spent":     content.HoursSpent,
+			"status":          ReportSubmitted,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("report_id = ?", report.ID).Delete(&ReportAttachment{}).Error; err != nil {
+			return err
+		}
+		attachments := content.attachments()
+		for i := range attachments {
+			attachments[i].ReportID = report.ID
+		}
+		if len(attachments) > 0 {
+			if err := tx.Create(&attachments).Error; err != nil {
+				return err
+			}
+		}
+
+		if !wasReturned {
+			return nil
+		}
+		var student Student
+		if err := tx.First(&student, report.StudentID).Error; err != nil {
+			return err
+		}
+		return NotifyReportResubmitted(tx, report.RecipientID, fmt.Sprintf("%s %s", student.FirstName, student.LastName), report.Year, report.Week)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetReportByID(db, report.ID)
+}
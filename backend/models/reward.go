@@ -0,0 +1,274 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reward is a catalog item students can buy with coins. Rewards without an organization are published by admins.
+type Reward struct {
+	gorm.Model
+	Name           string        `json:"name" gorm:"not null"`
+	Description    string        `json:"description"`
+	Kind           string        `json:"kind" gorm:"type:TEXT CHECK(kind IN ('ticket','merchandise','priority_review','other'));not null;default:'other'"`
+	Price          int           `json:"price" gorm:"not null;check:chk_rewards_price,price > 0"`
+	Stock          int           `json:"stock" gorm:"not null;check:chk_rewards_stock,stock >= 0"` // units left to redeem
+	Active         bool          `json:"active" gorm:"not null;default:true"`
+	OrganizationID *uint         `json:"organization_id" gorm:"index"`
+	Organization   *Organization `json:"organization,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:OrganizationID;references:ID"`
+}
+
+// Redemption is a student's purchase of a reward; the voucher code is presented to the issuer
+type Redemption struct {
+	gorm.Model
+	RewardID      uint             `json:"reward_id" gorm:"not null;index"`
+	Reward        *Reward          `json:"reward,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:RewardID;references:ID"`
+	StudentID     uint             `json:"student_id" gorm:"not null;index"`
+	Student       *Student         `json:"student,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:StudentID;references:ID"`
+	Price         int              `json:"price" gorm:"not null"` // coins paid, kept in case the reward price changes later
+	VoucherCode   string           `json:"voucher_code" gorm:"not null;uniqueIndex"`
+	TransactionID *uint            `json:"transaction_id"`
+	Transaction   *CoinTransaction `json:"-" gorm:"foreignKey:TransactionID;references:ID"`
+	UsedAt        *time.Time       `json:"used_at"`
+}
+
+// RewardInput holds the editable fields of a reward
+type RewardInput struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Kind        string `json:"kind"`
+	Price       int    `json:"price" binding:"required"`
+	Stock       int    `json:"stock"`
+	Active      *bool  `json:"active"`
+}
+
+func (in *RewardInput) validate() error {
+	if in.Kind == "" {
+		in.Kind = "other"
+	}
+	if in.Kind != "ticket" && in.Kind != "merchandise" && in.Kind != "priority_review" && in.Kind != "other" {
+		return fmt.Errorf("%w: invalid reward kind", gorm.ErrInvalidValue)
+	}
+	if in.Price <= 0 {
+		return fmt.Errorf("%w: price must be positive", gorm.ErrInvalidValue)
+	}
+	if in.Stock < 0 {
+		return fmt.Errorf("%w: stock cannot be negative", gorm.ErrInvalidValue)
+	}
+	return nil
+}
+
+// CreateReward publishes a reward; organizationID is nil for admin-issued rewards
+func CreateReward(db *gorm.DB, organizationID *uint, input RewardInput) (*Reward, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	reward := Reward{
+		Name:           input.Name,
+		Description:    input.Description,
+		Kind:           input.Kind,
+		Price:          input.Price,
+		Stock:          input.Stock,
+		Active:         input.Active == nil || *input.Active,
+		OrganizationID: organizationID,
+	}
+	if err := db.Create(&reward).Error; err != nil {
+		return nil, err
+	}
+	return GetRewardByID(db, reward.ID)
+}
+
+// GetRewardByID retrieves a reward by ID
+func GetRewardByID(db *gorm.DB, id uint) (*Reward, error) {
+	var reward Reward
+	if err := db.Preload("Organization").First(&reward, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("reward not found")
+		}
+		return nil, err
+	}
+	return &reward, nil
+}
+
+// GetActiveRewards lists rewards students can currently redeem, cheapest first
+func GetActiveRewards(db *gorm.DB) ([]Reward, error) {
+	var rewards []Reward
+	err := db.
+		Preload("Organization").
+		Where("active = ? AND stock > 0", true).
+		Order("price ASC, name").
+		Find(&rewards).
+		Error
+	return rewards, err
+}
+
+// GetRewardsByIssuer lists every reward an organization (or, with nil, the admins) published
+func GetRewardsByIssuer(db *gorm.DB, organizationID *uint) ([]Reward, error) {
+	var rewards []Reward
+	query := db.Preload("Organization")
+	if organizationID == nil {
+		query = query.Where("organization_id IS NULL")
+	} else {
+		query = query.Where("organization_id = ?", *organizationID)
+	}
+	err := query.Order("created_at DESC").Find(&rewards).Error
+	return rewards, err
+}
+
+// UpdateReward replaces a reward's editable fields
+func UpdateReward(db *gorm.DB, reward *Reward, input RewardInput) (*Reward, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"name":        input.Name,
+		"description": input.Description,
+		"kind":        input.Kind,
+		"price":       input.Price,
+		"stock":       input.Stock,
+	}
+	if input.Active != nil {
+		updates["active"] = *input.Active
+	}
+	if err := db.Model(reward).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return GetRewardByID(db, reward.ID)
+}
+
+// DeleteReward removes a reward from the catalog; existing vouchers stay valid
+func DeleteReward(db *gorm.DB, id uint) error {
+	return db.Delete(&Reward{}, id).Error
+}
+
+// RedeemReward reserves one unit of stock and debits the student's coins in a single transaction,
+// so the student is never charged for a sold-out reward and stock is never handed out unpaid
+func RedeemReward(db *gorm.DB, rewardID, studentID uint) (*Redemption, error) {
+	var redemption Redemption
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var prices []int
+		if err := tx.Raw(
+			"UPDATE rewards SET stock = stock - 1, updated_at = ? WHERE id = ? AND deleted_at IS NULL AND active AND stock > 0 RETURNING price",
+			time.Now(), rewardID,
+		).Scan(&prices).Error; err != nil {
+			return err
+		}
+		if len(prices) == 0 {
+			if _, err := GetRewardByID(tx, rewardID); err != nil {
+				return err
+			}
+			return errors.New("reward is out of stock or no longer available")
+		}
+
+		code, err := generateVoucherCode()
+		if err != nil {
+			return err
+		}
+		redemption = Redemption{
+			RewardID:    rewardID,
+			StudentID:   studentID,
+			Price:       prices[0],
+			VoucherCode: code,
+		}
+		if err := tx.Create(&redemption).Error; err != nil {
+			return err
+		}
+
+		transaction, err := DecrementCoins(tx, studentID, prices[0], CoinEntry{
+			Reason:        "redemption",
+			ActorRole:     "student",
+			ActorID:       &studentID,
+			ReferenceType: "redemption",
+			ReferenceID:   &redemption.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&redemption).Update("transaction_id", transaction.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Preload("Reward.Organization").Preload("Student").First(&redemption, redemption.ID).Error; err != nil {
+			return err
+		}
+		if redemption.Reward == nil || redemption.Reward.OrganizationID == nil || redemption.Student == nil {
+			return nil
+		}
+		studentName := fmt.Sprintf("%s %s", redemption.Student.FirstName, redemption.Student.LastName)
+		return NotifyRewardRedeemed(tx, *redemption.Reward.OrganizationID, redemption.Reward.Name, studentName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// GetRedemptionsByStudentID lists a student's vouchers, newest first
+func GetRedemptionsByStudentID(db *gorm.DB, studentID uint) ([]Redemption, error) {
+	var redemptions []Redemption
+	err := db.
+		Preload("Reward.Organization").
+		Where("student_id = ?", studentID).
+		Order("created_at DESC").
+		Find(&redemptions).
+		Error
+	return redemptions, err
+}
+
+// GetRedemptionByVoucherCode looks up a voucher for verification, including soft-deleted rewards
+func GetRedemptionByVoucherCode(db *gorm.DB, code string) (*Redemption, error) {
+	var redemption Redemption
+	err := db.
+		Preload("Reward", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Student").
+		Where("voucher_code = ?", normalizeVoucherCode(code)).
+		First(&redemption).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("voucher not found")
+		}
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+// UseVoucher marks a voucher as consumed; each voucher can be used once
+func UseVoucher(db *gorm.DB, redemption *Redemption) error {
+	now := time.Now()
+	result := db.Model(redemption).Where("used_at IS NULL").Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("voucher has already been used")
+	}
+	redemption.UsedAt = &now
+	return nil
+}
+
+// generateVoucherCode returns a random code formatted like ABCDE-FGHIJ
+func generateVoucherCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeVoucherCode(code string) string {
+	code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SavedSearch stores a student's opportunity filter so they can be alerted about new matches
+type SavedSearch struct {
+	gorm.Model
+	StudentID   uint     `json:"student_id" gorm:"not null;index"`
+	Student     *Student `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:StudentID;references:ID"`
+	Name        string   `json:"name" gorm:"not null"`
+	Type        string   `json:"type" gorm:"type:TEXT CHECK(type IN ('','research','project','internship'));not null;default:''"` // empty matches any type
+	Keywords    string   `json:"keywords"`                                                                                        // matched against name, details and requirements
+	Tags        []Tag    `json:"tags" gorm:"many2many:saved_search_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`           // an opportunity must carry all of them
+	EmailAlerts bool     `json:"email_alerts" gorm:"default:false"`
+}
+
+// SavedSearchInput holds the user-editable fields of a saved search
+type SavedSearchInput struct {
+	Name        string `json:"name" binding:"required"`
+	Type        string `json:"type"`
+	Keywords    string `json:"keywords"`
+	TagIDs      []uint `json:"tag_ids"`
+	EmailAlerts bool   `json:"email_alerts"`
+}
+
+func (in SavedSearchInput) validate() error {
+	if in.Type != "" && in.Type != "research" && in.Type != "project" && in.Type != "internship" {
+		return fmt.Errorf("%w: invalid opportunity type", gorm.ErrInvalidValue)
+	}
+	return nil
+}
+
+// CreateSavedSearch saves a search for a student
+func CreateSavedSearch(db *gorm.DB, studentID uint, input SavedSearchInput) (*SavedSearch, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	search := SavedSearch{
+		StudentID:   studentID,
+		Name:        input.Name,
+		Type:        input.Type,
+		Keywords:    strings.TrimSpace(input.Keywords),
+		EmailAlerts: input.EmailAlerts,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tags, err := findTagsByIDs(tx, input.TagIDs)
+		if err != nil {
+			return err
+		}
+		search.Tags = tags
+		return tx.Create(&search).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetSavedSearchByID(db, search.ID, studentID)
+}
+
+// GetSavedSearchByID returns a saved search owned by the student
+func GetSavedSearchByID(db *gorm.DB, id, studentID uint) (*SavedSearch, error) {
+	var search SavedSearch
+	if err := db.Preload("Tags").Where("student_id = ?", studentID).First(&search, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("saved search not found")
+		}
+		return nil, err
+	}
+	return &search, nil
+}
+
+// GetSavedSearchesByStudentID returns all searches saved by a student
+func GetSavedSearchesByStudentID(db *gorm.DB, studentID uint) ([]SavedSearch, error) {
+	var searches []SavedSearch
+	err := db.
+		Preload("Tags").
+		Where("student_id = ?", studentID).
+		Order("created_at DESC").
+		Find(&searches).
+		Error
+	return searches, err
+}
+
+// UpdateSavedSearch replaces a saved search's filters
+func UpdateSavedSearch(db *gorm.DB, id, studentID uint, input SavedSearchInput) (*SavedSearch, error) {
+	if err := input.validate(); err != nil {
+		return nil, err
+	}
+
+	search, err := GetSavedSearchByID(db, id, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(search).Updates(map[string]interface{}{
+			"name":         input.Name,
+			"type":         input.Type,
+			"keywords":     strings.TrimSpace(input.Keywords),
+			"email_alerts": input.EmailAlerts,
+		}).Error; err != nil {
+			return err
+		}
+
+		tags, err := findTagsByIDs(tx, input.TagIDs)
+		if err != nil {
+			return err
+		}
+		return tx.Model(search).Association("Tags").Replace(tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetSavedSearchByID(db, id, studentID)
+}
+
+// DeleteSavedSearch deletes a saved search owned by the student
+func DeleteSavedSearch(db *gorm.DB, id, studentID uint) error {
+	result := db.Where("id = ? AND student_id = ?", id, studentID).Delete(&SavedSearch{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("saved search not found or unauthorized")
+	}
+	return nil
+}
+
+// Matches reports whether an opportunity satisfies the search's type, keywords and tags
+func (s SavedSearch) Matches(op *Opportunity) bool {
+	if s.Type != "" && s.Type != op.Type {
+		return false
+	}
+
+	if s.Keywords != "" {
+		haystack := strings.ToLower(op.Name + " " + op.Details + " " + op.Requirements)
+		for _, word := range strings.Fields(strings.ToLower(s.Keywords)) {
+			if !strings.Contains(haystack, word) {
+				return false
+			}
+		}
+	}
+
+	opTags := make(map[uint]bool, len(op.RequirementTags))
+	for _, tag := range op.RequirementTags {
+		opTags[tag.ID] = true
+	}
+	for _, tag := range s.Tags {
+		if !opTags[tag.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// NotifySavedSearchMatches alerts every student with a saved search matching a newly published opportunity.
+// Each student is notified once per opportunity even if several of their searches match.
+func NotifySavedSearchMatches(db *gorm.DB, op *Opportunity) {
+	var searches []SavedSearch
+	if err := db.
+		Preload("Tags").
+		Preload("Student").
+		Where("type = '' OR type = ?", op.Type).
+		Find(&searches).
+		Error; err != nil {
+		log.Printf("saved search matcher: %v", err)
+		return
+	}
+
+	// Group matches by student so each one is notified once, emailed if any matching search asks for it
+	type match struct {
+		search string
+		email  bool
+	}
+	matches := make(map[uint]*match)
+	var order []uint
+	for _, search := range searches {
+		if search.Student == nil || !search.Matches(op) {
+			continue
+		}
+		if m, ok := matches[search.StudentID]; ok {
+			m.email = m.email || search.EmailAlerts
+			continue
+		}
+		matches[search.StudentID] = &match{search: search.Name, email: search.EmailAlerts}
+		order = append(order, search.StudentID)
+	}
+
+	for _, studentID := range order {
+		m := matches[studentID]
+		title := "🔎 New opportunity matches your search"
+		message := fmt.Sprintf("'%s' matches your saved search '%s'", op.Name, m.search)
+		if err := Dispatch(db, NotificationEvent{
+			Type:          EventSavedSearchMatch,
+			RecipientID:   studentID,
+			RecipientRole: "student",
+			Title:         title,
+			Message:       message,
+			Level:         "info",
+			Email:         m.email,
+		}); err != nil {
+			log.Printf("saved search matcher: %v", err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ScheduledJob tracks when a background job last ran and is next due. It is shared by all replicas,
+// so a run is claimed by atomically moving NextRunAt forward.
+type ScheduledJob struct {
+	Name          string     `json:"name" gorm:"primarykey"`
+	NextRunAt     time.Time  `json:"next_run_at" gorm:"not null;index"`
+	LastStartedAt *time.Time `json:"last_started_at"`
+	LastEndedAt   *time.Time `json:"last_ended_at"`
+	LastError     string     `json:"last_error"`
+	RunBy         string     `json:"run_by"` // instance that claimed the last run
+}
+
+// EnsureScheduledJob registers a job on first start without touching an existing schedule
+func EnsureScheduledJob(db *gorm.DB, name string, firstRun time.Time) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&ScheduledJob{Name: name, NextRunAt: firstRun}).Error
+}
+
+// ClaimScheduledJob marks a due job as started and schedules its next run.
+// It returns false if the job is not due or another instance claimed it first.
+func ClaimScheduledJob(db *gorm.DB, name, instance string, now, nextRun time.Time) (bool, error) {
+	result := db.Model(&ScheduledJob{}).
+		Where("name = ? AND next_run_at <= ?", name, now).
+		Updates(map[string]interface{}{
+			"next_run_at":     nextRun,
+			"last_started_at": now,
+			"run_by":          instance,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// FinishScheduledJob records the outcome of a run
+func FinishScheduledJob(db *gorm.DB, name string, runErr error) error {
+	lastError := ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+	return db.Model(&ScheduledJob{}).
+		Where("name = ?", name).
+		Updates(map[string]interface{}{"last_ended_at": time.Now(), "last_error": lastError}).
+		Error
+}
+
+// GetScheduledJobs lists all background jobs and their status
+func GetScheduledJobs(db *gorm.DB) ([]ScheduledJob, error) {
+	var jobs []ScheduledJob
+	err := db.Order("name").Find(&jobs).Error
+	return jobs, err
+}
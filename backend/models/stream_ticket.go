@@ -0,0 +1,70 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// streamTicketTTL is how long a ticket can wait before it is redeemed
+const streamTicketTTL = 30 * time.Second
+
+// ErrInvalidStreamTicket is returned for unknown, used or expired tickets
+var ErrInvalidStreamTicket = errors.New("invalid or expired stream ticket")
+
+// StreamTicket lets an EventSource, which cannot send an Authorization header, open the notification
+// stream without putting the JWT in the URL. Tickets are short-lived and single-use; only a hash is stored.
+type StreamTicket struct {
+	ID            uint      `gorm:"primaryKey"`
+	TokenHash     string    `gorm:"size:64;uniqueIndex;not null"`
+	RecipientID   uint      `gorm:"not null"`
+	RecipientRole string    `gorm:"size:20;not null"`
+	ExpiresAt     time.Time `gorm:"index;not null"`
+	CreatedAt     time.Time
+}
+
+func hashStreamTicket(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueStreamTicket creates a ticket for the recipient and returns it with its expiry
+func IssueStreamTicket(db *gorm.DB, recipientID uint, recipientRole string) (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	ticket := base64.RawURLEncoding.EncodeToString(buf)
+	expiresAt := time.Now().Add(streamTicketTTL)
+
+	// Expired tickets are never redeemed, so clear them out as new ones are issued
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&StreamTicket{}).Error; err != nil {
+		return "", time.Time{}, err
+	}
+	err := db.Create(&StreamTicket{
+		TokenHash:     hashStreamTicket(ticket),
+		RecipientID:   recipientID,
+		RecipientRole: recipientRole,
+		ExpiresAt:     expiresAt,
+	}).Error
+	return ticket, expiresAt, err
+}
+
+// RedeemStreamTicket consumes a ticket and returns who it was issued to
+func RedeemStreamTicket(db *gorm.DB, ticket string) (uint, string, error) {
+	var redeemed []StreamTicket
+	err := db.Raw("DELETE FROM stream_tickets WHERE token_hash = ? AND expires_at > ? RETURNING *",
+		hashStreamTicket(ticket), time.Now()).Scan(&redeemed).Error
+	if err != nil {
+		return 0, "", err
+	}
+	if len(redeemed) == 0 {
+		return 0, "", ErrInvalidStreamTicket
+	}
+	return redeemed[0].RecipientID, redeemed[0].RecipientRole, nil
+}
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	jwt_service "github.com/OmarDardery/solve-the-x-backend/jwt_service"
-	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
 	"gorm.io/gorm"
 )
 
@@ -16,8 +15,11 @@ type Student struct {
 	Email               string `gorm:"unique"`
 	Password            string
 	LastChangedPassword time.Time
-	Tags                []Tag `gorm:"many2many:student_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	Coins               Coins `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Tags                []Tag  `gorm:"many2many:student_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Coins               Coins  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	LeaderboardOptOut   bool   `gorm:"not null;default:false"`        // hide the student from public leaderboards
+	IsAdmin             bool   `gorm:"not null;default:false"`        // set from ADMIN_EMAILS at startup
+	Locale              string `gorm:"size:10;not null;default:'en'"` // language of the emails they receive
 }
 
 // Generate JWT for the student
@@ -44,7 +46,7 @@ func AuthenticateStudent(db *gorm.DB, email, password string) (*Student, error)
 }
 
 // CreateStudent registers a new student and automatically creates a Coins record
-func CreateStudent(db *gorm.DB, firstName, lastName, email, password string) error {
+func CreateStudent(db *gorm.DB, firstName, lastName, email, password, locale string) error {
 	var existing Student
 	if err := db.Where("email = ?", email).First(&existing).Error; err == nil {
 		return errors.New("email already registered")
@@ -63,6 +65,7 @@ func CreateStudent(db *gorm.DB, firstName, lastName, email, password string) err
 		Email:               email,
 		Password:            passwordHash,
 		LastChangedPassword: time.Now(),
+		Locale:              locale,
 	}
 
 	return db.Transaction(func(tx *gorm.DB) error {
@@ -78,14 +81,6 @@ func CreateStudent(db *gorm.DB, firstName, lastName, email, password string) err
 	})
 }
 
-func (s Student) Notify(mailman *mail_service.Mailman, subject, content string) error {
-	if mailman == nil {
-		// Fallback to legacy function for backward compatibility
-		return mail_service.SendNotification(s.Email, subject, content)
-	}
-	return mailman.SendNotification(s.Email, subject, content)
-}
-
 // GetStudentByID retrieves a student by ID with related data (Tags, Coins)
 func GetStudentByID(db *gorm.DB, id uint) (*Student, error) {
 	var student Student
@@ -106,6 +101,11 @@ func UpdateStudent(db *gorm.DB, id uint, updates map[string]interface{}) (*Stude
 		return nil, err
 	}
 
+	updates, err = editableUpdates(updates, studentEditable)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if password is being updated
 	if newPasswordRaw, ok := updates["Password"]; ok {
 		newPassword, ok := newPasswordRaw.(string)
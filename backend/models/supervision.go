@@ -0,0 +1,69 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// A professor supervises a student when the student has an accepted application on an opportunity
+// the professor owns or edits; viewers do not supervise. Supervision decides who a student may report to
+// and whose reports a professor may read.
+const supervisionJoin = `
+	FROM applications a
+	JOIN opportunity_collaborators oc ON oc.opportunity_id = a.opportunity_id AND oc.deleted_at IS NULL AND oc.role IN ('owner','editor')
+	WHERE a.status = 'accepted' AND a.deleted_at IS NULL`
+
+// IsSupervisor reports whether the professor supervises the student, optionally on a specific opportunity
+func IsSupervisor(db *gorm.DB, professorID, studentID uint, opportunityID *uint) (bool, error) {
+	query := "SELECT COUNT(*)" + supervisionJoin + " AND oc.professor_id = ? AND a.student_id = ?"
+	args := []interface{}{professorID, studentID}
+	if opportunityID != nil {
+		query += " AND a.opportunity_id = ?"
+		args = append(args, *opportunityID)
+	}
+
+	var count int64
+	if err := db.Raw(query, args...).Scan(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetSupervisorsByStudentID lists the professors a student may send reports to
+func GetSupervisorsByStudentID(db *gorm.DB, studentID uint) ([]Professor, error) {
+	professors := []Professor{}
+	err := db.
+		Where("id IN (SELECT oc.professor_id"+supervisionJoin+" AND a.student_id = ?)", studentID).
+		Order("last_name, first_name").
+		Find(&professors).
+		Error
+	return professors, err
+}
+
+// GetSupervisedStudents lists the students a professor supervises
+func GetSupervisedStudents(db *gorm.DB, professorID uint) ([]Student, error) {
+	students := []Student{}
+	err := db.
+		Preload("Tags").
+		Where("id IN (SELECT a.student_id"+supervisionJoin+" AND oc.professor_id = ?)", professorID).
+		Order("last_name, first_name").
+		Find(&students).
+		Error
+	return students, err
+}
+
+// GetReportsForSupervisor returns a supervised student's reports that a professor may read:
+// those addressed to the professor and those about opportunities the professor owns or edits
+func GetReportsForSupervisor(db *gorm.DB, professorID, studentID uint) ([]WeeklyReport, error) {
+	reports := []WeeklyReport{}
+	err := db.
+		Preload("Student").
+		Preload("Opportunity").
+		Preload("Attachments").
+		Where("student_id = ?", studentID).
+		Where(db.Where("recipient_id = ?", professorID).
+			Or("opportunity_id IN (SELECT opportunity_id FROM opportunity_collaborators WHERE professor_id = ? AND role IN ('owner','editor') AND deleted_at IS NULL)", professorID)).
+		Order("year DESC, week DESC, created_at DESC").
+		Find(&reports).
+		Error
+	return reports, err
+}
@@ -2,45 +2,130 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"unicode"
 
 	"gorm.io/gorm"
 )
 
+// ErrUnknownTag is returned when a referenced tag ID does not exist
+var ErrUnknownTag = errors.New("unknown tag id")
+
+// ErrTagExists is returned when a concurrent request created a tag with the same name first
+var ErrTagExists = errors.New("a tag with that name already exists")
+
+var errTagNameEmpty = fmt.Errorf("%w: tag name must contain letters or digits", gorm.ErrInvalidValue)
+
 type Tag struct {
 	gorm.Model
 	Name        string `json:"name" gorm:"unique;not null"`
+	Slug        string `json:"slug" gorm:"uniqueIndex"` // Normalized name, e.g. "Machine Learning" -> "machine-learning"
 	Description string `json:"description"`
+	// Hierarchy: a tag can belong to a parent category
+	ParentID *uint      `json:"parent_id"`
+	Parent   *Tag       `json:"parent,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:ParentID;references:ID"`
+	Children []Tag      `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+	Aliases  []TagAlias `json:"aliases,omitempty" gorm:"foreignKey:TagID"`
+}
+
+// TagAlias is an alternative name that resolves to a single canonical tag (e.g. "ML" -> "Machine Learning")
+type TagAlias struct {
+	gorm.Model
+	Name  string `json:"name" gorm:"not null"`
+	Slug  string `json:"slug" gorm:"uniqueIndex;not null"`
+	TagID uint   `json:"tag_id" gorm:"not null;index"`
+	Tag   *Tag   `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:TagID;references:ID"`
+}
+
+// Slugify normalizes a tag name so that case, spacing and punctuation variants collide
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
 }
 
-func CreateTag(db *gorm.DB, name, description string) (*Tag, error) {
-	var existing Tag
-	if err := db.Where("name = ?", name).First(&existing).Error; err == nil {
-		return &existing, nil // Tag already exists, return it
+// CreateTag creates a tag, or returns the existing canonical tag if the name (or one of its aliases) is already taken
+func CreateTag(db *gorm.DB, name, description string, parentID *uint) (*Tag, error) {
+	name = strings.TrimSpace(name)
+	slug := Slugify(name)
+	if slug == "" {
+		return nil, errTagNameEmpty
+	}
+
+	existing, err := ResolveTag(db, name)
+	if err == nil {
+		return existing, nil // Tag already exists, return it
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
 
+	if parentID != nil {
+		if _, err := GetTagByID(db, *parentID); err != nil {
+			return nil, fmt.Errorf("%w: parent tag not found", gorm.ErrInvalidValue)
+		}
+	}
+
 	tag := &Tag{
 		Name:        name,
+		Slug:        slug,
 		Description: description,
+		ParentID:    parentID,
 	}
 	if err := db.Create(tag).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrTagExists
+		}
 		return nil, err
 	}
 	return tag, nil
 }
 
+// ResolveTag finds the canonical tag for a name by matching its slug against tags and aliases
+func ResolveTag(db *gorm.DB, name string) (*Tag, error) {
+	slug := Slugify(name)
+	if slug == "" {
+		return nil, errTagNameEmpty
+	}
+
+	var tag Tag
+	err := db.Where("slug = ?", slug).First(&tag).Error
+	if err == nil {
+		return &tag, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var alias TagAlias
+	if err := db.Where("slug = ?", slug).First(&alias).Error; err != nil {
+		return nil, err
+	}
+	if err := db.First(&tag, alias.TagID).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
 // GetAllTags retrieves all tags
 func GetAllTags(db *gorm.DB) ([]Tag, error) {
 	var tags []Tag
-	err := db.Find(&tags).Error
+	err := db.Preload("Aliases").Find(&tags).Error
 	return tags, err
 }
 
 // GetTagByID retrieves a tag by ID
 func GetTagByID(db *gorm.DB, id uint) (*Tag, error) {
 	var tag Tag
-	err := db.First(&tag, id).Error
+	err := db.Preload("Parent").Preload("Children").Preload("Aliases").First(&tag, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("tag not found")
@@ -49,3 +134,201 @@ func GetTagByID(db *gorm.DB, id uint) (*Tag, error) {
 	}
 	return &tag, nil
 }
+
+// SetTagParent moves a tag under a parent category (nil makes it a root tag), rejecting cycles
+func SetTagParent(db *gorm.DB, tagID uint, parentID *uint) (*Tag, error) {
+	tag, err := GetTagByID(db, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk up from the new parent; reaching the tag itself would create a cycle
+	for next := parentID; next != nil; {
+		if *next == tagID {
+			return nil, errors.New("a tag cannot be its own ancestor")
+		}
+		var ancestor Tag
+		if err := db.First(&ancestor, *next).Error; err != nil {
+			return nil, errors.New("parent tag not found")
+		}
+		next = ancestor.ParentID
+	}
+
+	if err := db.Model(tag).Update("parent_id", parentID).Error; err != nil {
+		return nil, err
+	}
+	return GetTagByID(db, tagID)
+}
+
+// AddTagAlias registers an alternative name for a tag
+func AddTagAlias(db *gorm.DB, tagID uint, name string) (*TagAlias, error) {
+	name = strings.TrimSpace(name)
+	slug := Slugify(name)
+	if slug == "" {
+		return nil, errors.New("alias must contain letters or digits")
+	}
+
+	if _, err := GetTagByID(db, tagID); err != nil {
+		return nil, err
+	}
+
+	if existing, err := ResolveTag(db, name); err == nil {
+		return nil, fmt.Errorf("name already used by tag '%s'", existing.Name)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	alias := &TagAlias{
+		Name:  name,
+		Slug:  slug,
+		TagID: tagID,
+	}
+	if err := db.Create(alias).Error; err != nil {
+		return nil, err
+	}
+	return alias, nil
+}
+
+// DeleteTagAlias removes an alias
+func DeleteTagAlias(db *gorm.DB, aliasID uint) error {
+	result := db.Unscoped().Delete(&TagAlias{}, aliasID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("alias not found")
+	}
+	return nil
+}
+
+// MergeTags folds the source tag into the target: student and opportunity associations, aliases and
+// child tags move to the target, the source name becomes an alias, and the source tag is removed
+func MergeTags(db *gorm.DB, sourceID, targetID uint) (*Tag, error) {
+	if sourceID == targetID {
+		return nil, errors.New("cannot merge a tag into itself")
+	}
+
+	source, err := GetTagByID(db, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := GetTagByID(db, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Move associations, skipping rows that already point to the target
+		if err := tx.Exec(
+			"INSERT INTO student_tags (student_id, tag_id) SELECT student_id, ? FROM student_tags WHERE tag_id = ? ON CONFLICT DO NOTHING",
+			target.ID, source.ID,
+		).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM student_tags WHERE tag_id = ?", source.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(
+			"INSERT INTO opportunity_tags (opportunity_id, tag_id) SELECT opportunity_id, ? FROM opportunity_tags WHERE tag_id = ? ON CONFLICT DO NOTHING",
+			target.ID, source.ID,
+		).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM opportunity_tags WHERE tag_id = ?", source.ID).Error; err != nil {
+			return err
+		}
+
+		// If the target sits anywhere under the source, lift it to the source's parent first;
+		// otherwise handing the source's children to the target would close a cycle
+		for next := target.ParentID; next != nil; {
+			if *next == source.ID {
+				if err := tx.Model(&Tag{}).Where("id = ?", target.ID).Update("parent_id", source.ParentID).Error; err != nil {
+					return err
+				}
+				break
+			}
+			var ancestor Tag
+			if err := tx.First(&ancestor, *next).Error; err != nil {
+				return err
+			}
+			next = ancestor.ParentID
+		}
+		if err := tx.Model(&Tag{}).Where("parent_id = ?", source.ID).Update("parent_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&TagAlias{}).Where("tag_id = ?", source.ID).Update("tag_id", target.ID).Error; err != nil {
+			return err
+		}
+
+		// Hard delete so the source slug is free to live on as an alias
+		if err := tx.Unscoped().Delete(&Tag{}, source.ID).Error; err != nil {
+			return err
+		}
+		if source.Slug != "" && source.Slug != target.Slug {
+			alias := &TagAlias{Name: source.Name, Slug: source.Slug, TagID: target.ID}
+			if err := tx.Create(alias).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return GetTagByID(db, target.ID)
+}
+
+// BackfillTagSlugs fills in slugs for tags created before slugs existed, merging tags whose names normalize to the same slug
+func BackfillTagSlugs(db *gorm.DB) error {
+	var tags []Tag
+	if err := db.Where("slug IS NULL OR slug = ''").Order("id").Find(&tags).Error; err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		slug := Slugify(tag.Name)
+		if slug == "" {
+			slug = fmt.Sprintf("tag-%d", tag.ID)
+		}
+
+		existing, err := ResolveTag(db, slug)
+		if err == nil && existing.ID != tag.ID {
+			if _, err := MergeTags(db, tag.ID, existing.ID); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := db.Model(&Tag{}).Where("id = ?", tag.ID).Update("slug", slug).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findTagsByIDs loads the tags with the given IDs and fails if any of them does not exist
+func findTagsByIDs(db *gorm.DB, ids []uint) ([]Tag, error) {
+	tags := []Tag{}
+	if len(ids) == 0 {
+		return tags, nil
+	}
+
+	if err := db.Find(&tags, ids).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[uint]bool, len(tags))
+	for _, tag := range tags {
+		found[tag.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("%w: %d", ErrUnknownTag, id)
+		}
+	}
+	return tags, nil
+}
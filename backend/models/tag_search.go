@@ -0,0 +1,116 @@
+package models
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TagSuggestion is an autocomplete match; MatchedAlias is set when the query hit an alias rather than the tag name
+type TagSuggestion struct {
+	ID           uint    `json:"id"`
+	Name         string  `json:"name"`
+	Slug         string  `json:"slug"`
+	MatchedAlias string  `json:"matched_alias,omitempty"`
+	Score        float64 `json:"score"`
+}
+
+// TagStats summarizes how a tag is used across opportunities and students
+type TagStats struct {
+	ID                          uint   `json:"id"`
+	Name                        string `json:"name"`
+	Slug                        string `json:"slug"`
+	OpenOpportunities           int64  `json:"open_opportunities"`
+	Students                    int64  `json:"students"`
+	OpportunitiesLast30Days     int64  `json:"opportunities_last_30_days"`
+	OpportunitiesPrevious30Days int64  `json:"opportunities_previous_30_days"`
+	Trend                       int64  `json:"trend"` // last 30 days minus the 30 days before
+}
+
+// EnableTagSearch installs pg_trgm and the trigram indexes used by tag autocomplete
+func EnableTagSearch(db *gorm.DB) {
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+		"CREATE INDEX IF NOT EXISTS idx_tags_slug_trgm ON tags USING gin (slug gin_trgm_ops)",
+		"CREATE INDEX IF NOT EXISTS idx_tag_aliases_slug_trgm ON tag_aliases USING gin (slug gin_trgm_ops)",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Printf("Warning: tag search setup failed (%s): %v", stmt, err)
+			return
+		}
+	}
+}
+
+// AutocompleteTags matches a query against tag and alias slugs by prefix and trigram similarity,
+// returning each canonical tag once with prefix matches ranked first
+func AutocompleteTags(db *gorm.DB, query string, limit int) ([]TagSuggestion, error) {
+	suggestions := []TagSuggestion{}
+	slug := Slugify(query)
+	if slug == "" {
+		return suggestions, nil
+	}
+
+	err := db.Raw(`
+		SELECT id, name, slug, matched_alias, score FROM (
+			SELECT DISTINCT ON (t.id)
+				t.id, t.name, t.slug, COALESCE(m.alias, '') AS matched_alias,
+				(CASE WHEN m.slug LIKE @prefix THEN 1 ELSE 0 END) + similarity(m.slug, @slug) AS score
+			FROM (
+				SELECT id AS tag_id, slug, NULL AS alias FROM tags WHERE deleted_at IS NULL
+				UNION ALL
+				SELECT tag_id, slug, name AS alias FROM tag_aliases WHERE deleted_at IS NULL
+			) m
+			JOIN tags t ON t.id = m.tag_id AND t.deleted_at IS NULL
+			WHERE m.slug LIKE @prefix OR m.slug % @slug
+			ORDER BY t.id, score DESC
+		) matches
+		ORDER BY score DESC, name
+		LIMIT @limit`,
+		map[string]interface{}{
+			"slug":   slug,
+			"prefix": slug + "%",
+			"limit":  limit,
+		},
+	).Scan(&suggestions).Error
+	return suggestions, err
+}
+
+// GetTagStats returns usage counts for every tag, most used first
+func GetTagStats(db *gorm.DB) ([]TagStats, error) {
+	now := time.Now()
+	stats := []TagStats{}
+	err := db.Raw(`
+		SELECT
+			t.id, t.name, t.slug,
+			(SELECT COUNT(*) FROM opportunity_tags ot
+				JOIN opportunities o ON o.id = ot.opportunity_id AND o.deleted_at IS NULL
+				WHERE ot.tag_id = t.id AND o.status = @open) AS open_opportunities,
+			(SELECT COUNT(*) FROM student_tags st
+				JOIN students s ON s.id = st.student_id AND s.deleted_at IS NULL
+				WHERE st.tag_id = t.id) AS students,
+			(SELECT COUNT(*) FROM opportunity_tags ot
+				JOIN opportunities o ON o.id = ot.opportunity_id AND o.deleted_at IS NULL
+				WHERE ot.tag_id = t.id AND o.created_at >= @since30) AS opportunities_last30_days,
+			(SELECT COUNT(*) FROM opportunity_tags ot
+				JOIN opportunities o ON o.id = ot.opportunity_id AND o.deleted_at IS NULL
+				WHERE ot.tag_id = t.id AND o.created_at >= @since60 AND o.created_at < @since30) AS opportunities_previous30_days
+		FROM tags t
+		WHERE t.deleted_at IS NULL
+		ORDER BY open_opportunities DESC, students DESC, t.name`,
+		map[string]interface{}{
+			"open":    OpportunityOpen,
+			"since30": now.AddDate(0, 0, -30),
+			"since60": now.AddDate(0, 0, -60),
+		},
+	).Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range stats {
+		stats[i].Trend = stats[i].OpportunitiesLast30Days - stats[i].OpportunitiesPrevious30Days
+	}
+	return stats, nil
+}
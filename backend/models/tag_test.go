@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestMergeTagsIntoDescendant(t *testing.T) {
+	db := openTestDB(t, &Professor{}, &Organization{}, &Tag{}, &TagAlias{}, &Student{}, &Opportunity{})
+
+	root, err := CreateTag(db, "Merge Test Root", "", nil)
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	source, err := CreateTag(db, "Merge Test Source", "", &root.ID)
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	child, err := CreateTag(db, "Merge Test Child", "", &source.ID)
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	grandchild, err := CreateTag(db, "Merge Test Grandchild", "", &child.ID)
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+
+	merged, err := MergeTags(db, source.ID, grandchild.ID)
+	if err != nil {
+		t.Fatalf("MergeTags: %v", err)
+	}
+	if merged.ParentID == nil || *merged.ParentID != root.ID {
+		t.Errorf("merged parent = %v, want %d", merged.ParentID, root.ID)
+	}
+
+	// The former child now hangs off the merged tag, and walking up from it must reach the root
+	moved, err := GetTagByID(db, child.ID)
+	if err != nil {
+		t.Fatalf("GetTagByID: %v", err)
+	}
+	if moved.ParentID == nil || *moved.ParentID != grandchild.ID {
+		t.Errorf("child parent = %v, want %d", moved.ParentID, grandchild.ID)
+	}
+	seen := map[uint]bool{}
+	for next := &child.ID; next != nil; {
+		if seen[*next] {
+			t.Fatalf("cycle through tag %d", *next)
+		}
+		seen[*next] = true
+		var tag Tag
+		if err := db.First(&tag, *next).Error; err != nil {
+			t.Fatalf("load tag %d: %v", *next, err)
+		}
+		next = tag.ParentID
+	}
+
+	// The source name still resolves, now to the merged tag
+	resolved, err := ResolveTag(db, "merge test source")
+	if err != nil || resolved.ID != grandchild.ID {
+		t.Errorf("ResolveTag(source) = %v, %v; want tag %d", resolved, err, grandchild.ID)
+	}
+}
+
+func TestCreateTagRejectsInvalidInput(t *testing.T) {
+	db := openTestDB(t, &Tag{}, &TagAlias{})
+
+	if _, err := CreateTag(db, "  !! ", "", nil); !errors.Is(err, gorm.ErrInvalidValue) {
+		t.Errorf("empty name error = %v, want ErrInvalidValue", err)
+	}
+	missing := uint(1 << 30)
+	if _, err := CreateTag(db, "Orphan Tag", "", &missing); !errors.Is(err, gorm.ErrInvalidValue) {
+		t.Errorf("unknown parent error = %v, want ErrInvalidValue", err)
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// openTestDB connects to TEST_DATABASE_URL, migrates the given models and returns a transaction
+// that is rolled back when the test ends. Tests that need it are skipped without a database.
+func openTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	tx := db.Begin()
+	if tx.Error != nil {
+		t.Fatalf("begin: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
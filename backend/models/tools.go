@@ -1,6 +1,9 @@
 package models
 
 import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -13,3 +16,31 @@ func CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// uintSlice converts a JSON-decoded array (e.g. from a map[string]interface{} body) into IDs
+func uintSlice(raw interface{}) ([]uint, bool) {
+	switch v := raw.(type) {
+	case nil:
+		return []uint{}, true
+	case []uint:
+		return v, true
+	case []interface{}:
+		ids := make([]uint, 0, len(v))
+		for _, item := range v {
+			n, ok := item.(float64)
+			if !ok || n < 0 || n != float64(uint(n)) {
+				return nil, false
+			}
+			ids = append(ids, uint(n))
+		}
+		return ids, true
+	default:
+		return nil, false
+	}
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a row that breaks a unique index
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: "23505"}, true},
+		{fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"}), true},
+		{&pgconn.PgError{Code: "23503"}, false}, // foreign key violation
+		{errors.New("duplicate key value violates unique constraint"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isUniqueViolation(tt.err); got != tt.want {
+			t.Errorf("isUniqueViolation(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
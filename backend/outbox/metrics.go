@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics counts this replica's deliveries since it started
+type Metrics struct {
+	Sent             int64            `json:"sent"`
+	Failed           int64            `json:"failed"`  // failed attempts, including ones that will be retried
+	Retried          int64            `json:"retried"` // failures scheduled for another attempt
+	Dead             int64            `json:"dead"`
+	ByProvider       map[string]int64 `json:"sent_by_provider"`
+	AverageLatencyMs float64          `json:"average_latency_ms"`
+}
+
+type counters struct {
+	sent, failed, retried, dead atomic.Int64
+
+	mu         sync.Mutex
+	byProvider map[string]int64
+	latency    time.Duration
+}
+
+var metrics = &counters{byProvider: map[string]int64{}}
+
+func (c *counters) observe(provider string, latency time.Duration, err error) {
+	if err != nil {
+		c.failed.Add(1)
+		return
+	}
+	c.sent.Add(1)
+	c.mu.Lock()
+	c.byProvider[provider]++
+	c.latency += latency
+	c.mu.Unlock()
+}
+
+// Snapshot returns the current delivery metrics
+func Snapshot() Metrics {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	m := Metrics{
+		Sent:       metrics.sent.Load(),
+		Failed:     metrics.failed.Load(),
+		Retried:    metrics.retried.Load(),
+		Dead:       metrics.dead.Load(),
+		ByProvider: make(map[string]int64, len(metrics.byProvider)),
+	}
+	for provider, count := range metrics.byProvider {
+		m.ByProvider[provider] = count
+	}
+	if m.Sent > 0 {
+		m.AverageLatencyMs = float64(metrics.latency.Milliseconds()) / float64(m.Sent)
+	}
+	return m
+}
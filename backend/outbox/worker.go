@@ -0,0 +1,163 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OmarDardery/solve-the-x-backend/mail_service"
+	"github.com/OmarDardery/solve-the-x-backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAttempts  = 8
+	baseBackoff  = 30 * time.Second
+	maxBackoff   = 2 * time.Hour
+	staleAfter   = 10 * time.Minute // a claimed email older than this belongs to a dead worker
+	pollInterval = 2 * time.Second
+)
+
+// defaultRates is the per-second send limit for each provider, overridable with MAIL_RATE_<PROVIDER>.
+// The limit is shared by every replica.
+var defaultRates = map[string]float64{
+	"sendgrid": 10,
+	"smtp":     2,
+}
+
+// Pool drains the email outbox with a fixed number of workers. It is safe to run on every replica.
+type Pool struct {
+	db       *gorm.DB
+	mailer   mail_service.Mailer
+	workers  int
+	limiters map[string]*rateLimiter
+}
+
+// NewPool creates a pool of workers sending through mailer
+func NewPool(db *gorm.DB, mailer mail_service.Mailer, workers int) *Pool {
+	limiters := map[string]*rateLimiter{}
+	for provider, rate := range defaultRates {
+		if env, err := strconv.ParseFloat(os.Getenv("MAIL_RATE_"+strings.ToUpper(provider)), 64); err == nil && env > 0 {
+			rate = env
+		}
+		limiters[provider] = newRateLimiter(db, provider, rate)
+	}
+	return &Pool{db: db, mailer: mailer, workers: workers, limiters: limiters}
+}
+
+// Start runs the workers in the background until ctx is cancelled
+func (p *Pool) Start(ctx context.Context) {
+	for i := 0; i < p.workers; i++ {
+		go p.work(ctx)
+	}
+}
+
+func (p *Pool) work(ctx context.Context) {
+	for {
+		emails, err := models.ClaimOutboxEmails(p.db, 1, staleAfter)
+		if err != nil {
+			log.Printf("outbox: claim: %v", err)
+		}
+		if len(emails) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		for _, email := range emails {
+			p.deliver(ctx, email)
+		}
+	}
+}
+
+func (p *Pool) deliver(ctx context.Context, email models.OutboxEmail) {
+	provider := p.mailer.Provider()
+
+	// the address may have bounced after the email was queued
+	if suppressed, err := models.IsEmailSuppressed(p.db, email.To); err != nil {
+		log.Printf("outbox: suppression check for %d: %v", email.ID, err)
+	} else if suppressed {
+		if err := models.MarkOutboxFailed(p.db, email.ID, provider, models.ErrAddressSuppressed, nil); err != nil {
+			log.Printf("outbox: mark %d failed: %v", email.ID, err)
+		}
+		return
+	}
+
+	if limiter, ok := p.limiters[provider]; ok {
+		if err := limiter.Wait(ctx); err != nil {
+			return // shutting down; the claim goes stale and another worker retries it
+		}
+	}
+
+	start := time.Now()
+	sendErr := p.mailer.Send(mail_service.Message{To: email.To, Subject: email.Subject, Text: email.Text, HTML: email.HTML, Headers: email.Headers})
+	metrics.observe(provider, time.Since(start), sendErr)
+
+	if sendErr == nil {
+		if err := models.MarkOutboxSent(p.db, email.ID, provider); err != nil {
+			log.Printf("outbox: mark %d sent: %v", email.ID, err)
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	if email.Attempts < maxAttempts {
+		next := time.Now().Add(backoff(email.Attempts))
+		retryAt = &next
+		metrics.retried.Add(1)
+	} else {
+		metrics.dead.Add(1)
+		log.Printf("outbox: email %d to %s dead-lettered after %d attempts: %v", email.ID, email.To, email.Attempts, sendErr)
+	}
+	if err := models.MarkOutboxFailed(p.db, email.ID, provider, sendErr, retryAt); err != nil {
+		log.Printf("outbox: mark %d failed: %v", email.ID, err)
+	}
+}
+
+// backoff doubles the delay after each attempt, with jitter so failed emails do not retry in lockstep
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff << (attempts - 1)
+	if delay > maxBackoff || delay <= 0 {
+		delay = maxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay) / 5))
+	return delay - delay/10 + jitter
+}
+
+// rateLimiter spaces sends evenly so a provider never sees more than its rate per second. Slots are
+// reserved in the database, so the rate holds across all replicas rather than per process.
+type rateLimiter struct {
+	db       *gorm.DB
+	provider string
+	interval time.Duration
+}
+
+func newRateLimiter(db *gorm.DB, provider string, perSecond float64) *rateLimiter {
+	return &rateLimiter{db: db, provider: provider, interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// Wait blocks until the next send slot, or returns ctx's error if it is cancelled first
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	wait, err := models.ReserveMailSlot(l.db.WithContext(ctx), l.provider, l.interval)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// Better to send than to stall the outbox; the provider throttles us if we overshoot
+		log.Printf("outbox: reserve %s send slot: %v", l.provider, err)
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
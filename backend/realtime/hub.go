@@ -0,0 +1,77 @@
+package realtime
+
+import (
+	"sync"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind before it is dropped
+const subscriberBuffer = 32
+
+// Event is a message delivered to subscribers of a topic. ID increases monotonically per topic
+// so clients can resume after reconnecting.
+type Event struct {
+	ID   uint
+	Name string
+	Data []byte
+}
+
+// Hub is an in-process pub/sub broker. Each topic (e.g. one user's notifications) can have many subscribers.
+type Hub struct {
+	mu     sync.RWMutex
+	topics map[string]map[chan Event]struct{}
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{topics: make(map[string]map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel receiving the topic's events and a function to stop listening.
+// The channel is closed when the subscriber unsubscribes or falls too far behind.
+func (h *Hub) Subscribe(topic string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.topics[topic] == nil {
+		h.topics[topic] = make(map[chan Event]struct{})
+	}
+	h.topics[topic][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() { h.remove(topic, ch) })
+	}
+}
+
+// Publish delivers an event to every current subscriber of a topic without blocking.
+// Subscribers whose buffer is full are disconnected; they can resume from their last event ID.
+func (h *Hub) Publish(topic string, event Event) {
+	h.mu.RLock()
+	var slow []chan Event
+	for ch := range h.topics[topic] {
+		select {
+		case ch <- event:
+		default:
+			slow = append(slow, ch)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, ch := range slow {
+		h.remove(topic, ch)
+	}
+}
+
+func (h *Hub) remove(topic string, ch chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.topics[topic][ch]; !ok {
+		return
+	}
+	delete(h.topics[topic], ch)
+	if len(h.topics[topic]) == 0 {
+		delete(h.topics, topic)
+	}
+	close(ch)
+}
@@ -0,0 +1,50 @@
+package realtime
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Listen subscribes to a Postgres NOTIFY channel and calls handle with each payload until ctx is cancelled.
+// It holds its own connection and reconnects with backoff if the connection drops.
+func Listen(ctx context.Context, dsn, channel string, handle func(payload string)) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		err := listenOnce(ctx, dsn, channel, handle, func() { backoff = time.Second })
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("realtime: listener on %s stopped: %v (retrying in %s)", channel, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func listenOnce(ctx context.Context, dsn, channel string, handle func(string), connected func()) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	connected()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		handle(notification.Payload)
+	}
+}
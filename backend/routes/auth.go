@@ -34,8 +34,8 @@ type SignInInput struct {
 	Password string `json:"password" binding:"required,min=8"`
 }
 
-// SendCodeHandler sends a verification code to the provided email
-func SendCodeHandler(db *gorm.DB, codes *map[string]int, mailman *mail_service.Mailman) gin.HandlerFunc {
+// SendCodeHandler queues a verification code email to the provided address
+func SendCodeHandler(db *gorm.DB, codes *map[string]int) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		var input struct {
 			Email string `json:"email" binding:"required,email"`
@@ -44,10 +44,19 @@ func SendCodeHandler(db *gorm.DB, codes *map[string]int, mailman *mail_service.M
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		// the code would be silently dropped; tell the user to pick another address instead
+		if suppressed, err := models.IsEmailSuppressed(db, input.Email); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		} else if suppressed {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "emails to this address bounced or were reported as spam; use a different address"})
+			return
+		}
 		(*codes)[input.Email] = rand.Intn(900000) + 100000 // ensures value is between 100000–999999
 
-		err := mailman.SendVerificationEmail(input.Email, fmt.Sprintf("%06d", (*codes)[input.Email]))
-		if err != nil {
+		locale := mail_service.MatchLocale(ctx.GetHeader("Accept-Language"))
+		code := mail_service.VerificationData{Code: fmt.Sprintf("%06d", (*codes)[input.Email])}
+		if err := models.EnqueueEmail(db, input.Email, "", "verification", locale, code, nil); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -59,9 +68,11 @@ func SendCodeHandler(db *gorm.DB, codes *map[string]int, mailman *mail_service.M
 }
 
 // SignUpHandler handles user registration for students, professors, and organizations
-func SignUpHandler(db *gorm.DB, codes *map[string]int, mailman *mail_service.Mailman) gin.HandlerFunc {
+func SignUpHandler(db *gorm.DB, codes *map[string]int) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		role := ctx.Param("role")
+		// Emails to the new account go out in the language their browser asked for; they can change it in their profile
+		locale := mail_service.MatchLocale(ctx.GetHeader("Accept-Language"))
 
 		var err error
 
@@ -78,7 +89,7 @@ func SignUpHandler(db *gorm.DB, codes *map[string]int, mailman *mail_service.Mai
 				return
 			}
 
-			err = models.CreateOrganization(db, input.Name, input.Email, input.Password, input.Contact, input.Link)
+			err = models.CreateOrganization(db, input.Name, input.Email, input.Password, input.Contact, input.Link, locale)
 			if err != nil {
 				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
@@ -104,9 +115,9 @@ func SignUpHandler(db *gorm.DB, codes *map[string]int, mailman *mail_service.Mai
 
 		switch role {
 		case "student":
-			err = models.CreateStudent(db, input.FirstName, input.LastName, input.Email, input.Password)
+			err = models.CreateStudent(db, input.FirstName, input.LastName, input.Email, input.Password, locale)
 		case "professor":
-			err = models.CreateProfessor(db, input.FirstName, input.LastName, input.Email, input.Password)
+			err = models.CreateProfessor(db, input.FirstName, input.LastName, input.Email, input.Password, locale)
 		default:
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
 			return
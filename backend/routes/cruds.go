@@ -1,10 +1,20 @@
 package routes
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/OmarDardery/solve-the-x-backend/mail_service"
 	"github.com/OmarDardery/solve-the-x-backend/models"
+	"github.com/OmarDardery/solve-the-x-backend/outbox"
+	"github.com/OmarDardery/solve-the-x-backend/realtime"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -36,6 +46,89 @@ func requireRole(c *gin.Context, role string) bool {
 	return true
 }
 
+// Require admin helper (admin status is synced from ADMIN_EMAILS at startup)
+func requireAdmin(c *gin.Context) bool {
+	if !c.GetBool("is_admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only admins can perform this action"})
+		return false
+	}
+	return true
+}
+
+// Require the professor to hold at least minRole (owner/editor/viewer) on an opportunity
+func requireCollaboratorRole(c *gin.Context, db *gorm.DB, opportunityID, professorID uint, minRole string) bool {
+	role, err := models.GetCollaboratorRole(db, opportunityID, professorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if !models.RoleAtLeast(role, minRole) {
+		c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("requires %s access to this opportunity", minRole)})
+		return false
+	}
+	return true
+}
+
+// Require the current professor (by collaborator role) or organization (as owner) to have access to an opportunity
+func requireOpportunityAccess(c *gin.Context, db *gorm.DB, op *models.Opportunity, minRole string) bool {
+	role, _ := c.Get("role")
+	switch role {
+	case "professor":
+		prof, ok := getUser[models.Professor](c)
+		if !ok {
+			return false
+		}
+		return requireCollaboratorRole(c, db, op.ID, prof.ID, minRole)
+	case "organization":
+		org, ok := getUser[models.Organization](c)
+		if !ok {
+			return false
+		}
+		if op.OrganizationID == nil || *op.OrganizationID != org.ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "cannot access opportunities you don't own"})
+			return false
+		}
+		return true
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "only professors and organizations can manage opportunities"})
+		return false
+	}
+}
+
+// Require the opportunity to be visible to the current user, answering 404 otherwise.
+// Drafts are only visible to their organization and the professors collaborating on them.
+func requireVisibleOpportunity(c *gin.Context, db *gorm.DB, op *models.Opportunity) bool {
+	if op.Status != models.OpportunityDraft {
+		return true
+	}
+
+	visible := false
+	role, _ := c.Get("role")
+	switch role {
+	case "professor":
+		prof, ok := getUser[models.Professor](c)
+		if !ok {
+			return false
+		}
+		collaboratorRole, err := models.GetCollaboratorRole(db, op.ID, prof.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return false
+		}
+		visible = collaboratorRole != ""
+	case "organization":
+		org, ok := getUser[models.Organization](c)
+		if !ok {
+			return false
+		}
+		visible = op.OrganizationID != nil && *op.OrganizationID == org.ID
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "opportunity not found"})
+	}
+	return visible
+}
+
 // Bind JSON and handle errors
 func bindJSON[T any](c *gin.Context) (*T, bool) {
 	var input T
@@ -46,6 +139,21 @@ func bindJSON[T any](c *gin.Context) (*T, bool) {
 	return &input, true
 }
 
+// Parse an optional date (2006-01-02) or RFC 3339 timestamp query parameter, responding 400 if malformed
+func timeFromQuery(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a date (YYYY-MM-DD) or RFC 3339 timestamp"})
+	return nil, false
+}
+
 // Convert param to uint
 func uintFromParam(param string) uint {
 	var id uint
@@ -55,17 +163,24 @@ func uintFromParam(param string) uint {
 
 // ------------------ CRUD ROUTES ------------------
 
-func RegisterCRUDRoutes(api *gin.RouterGroup, db *gorm.DB) {
+func RegisterCRUDRoutes(api *gin.RouterGroup, db *gorm.DB, hub *realtime.Hub) {
 	registerStudentRoutes(api.Group("/students"), db)
 	registerProfessorRoutes(api.Group("/professors"), db)
 	registerOrganizationRoutes(api.Group("/organizations"), db)
 	registerEventRoutes(api.Group("/events"), db)
 	registerOpportunityRoutes(api.Group("/opportunities"), db)
+	registerBookmarkRoutes(api.Group("/bookmarks"), db)
+	registerSavedSearchRoutes(api.Group("/saved-searches"), db)
 	registerApplicationRoutes(api.Group("/applications"), db)
 	registerCoinRoutes(api.Group("/coins"), db)
+	registerRewardRoutes(api.Group("/rewards"), db)
 	registerReportRoutes(api.Group("/reports"), db)
-	registerNotificationRoutes(api.Group("/notifications"), db)
+	registerNotificationRoutes(api.Group("/notifications"), db, hub)
 	registerTagRoutes(api.Group("/tags"), db)
+	registerJobRoutes(api.Group("/jobs"), db)
+	registerEmailTemplateRoutes(api.Group("/email-templates"))
+	registerEmailOutboxRoutes(api.Group("/email-outbox"), db)
+	registerEmailStatusRoutes(api.Group("/email-status"), db)
 }
 
 // ------------------ STUDENTS ------------------
@@ -93,12 +208,39 @@ func registerStudentRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 
 		updated, err := models.UpdateStudent(db, student.ID, *updates)
 		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, updated)
 	})
 
+	// PUT /me/leaderboard - Opt out of (or back into) the public leaderboard
+	rg.PUT("/me/leaderboard", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+		input, ok := bindJSON[struct {
+			OptOut bool `json:"opt_out"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		if err := models.SetLeaderboardOptOut(db, student.ID, input.OptOut); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"opt_out": input.OptOut})
+	})
+
 	rg.DELETE("/me", func(c *gin.Context) {
 		student, ok := getUser[models.Student](c)
 		if !ok {
@@ -138,6 +280,10 @@ func registerProfessorRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 
 		updated, err := models.UpdateProfessor(db, prof.ID, *updates)
 		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -183,6 +329,10 @@ func registerOrganizationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 
 		updated, err := models.UpdateOrganization(db, org.ID, *updates)
 		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -244,11 +394,17 @@ func registerEventRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		}
 
 		input, ok := bindJSON[struct {
-			Title       string `json:"title" binding:"required"`
-			Description string `json:"description"`
-			Date        string `json:"date"`
-			Link        string `json:"link"`
-			SignUpLink  string `json:"sign_up_link"`
+			Title        string     `json:"title" binding:"required"`
+			Description  string     `json:"description"`
+			Date         string     `json:"date"`
+			StartsAt     *time.Time `json:"starts_at"`
+			EndsAt       *time.Time `json:"ends_at"`
+			Timezone     string     `json:"timezone"`
+			AllDay       bool       `json:"all_day"`
+			LocationMode string     `json:"location_mode"`
+			Location     string     `json:"location"`
+			Link         string     `json:"link"`
+			SignUpLink   string     `json:"sign_up_link"`
 		}](c)
 		if !ok {
 			return
@@ -258,12 +414,22 @@ func registerEventRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 			Title:          input.Title,
 			Description:    input.Description,
 			Date:           input.Date,
+			StartsAt:       input.StartsAt,
+			EndsAt:         input.EndsAt,
+			Timezone:       input.Timezone,
+			AllDay:         input.AllDay,
+			LocationMode:   input.LocationMode,
+			Location:       input.Location,
 			Link:           input.Link,
 			SignUpLink:     input.SignUpLink,
 			OrganizationID: org.ID,
 		}
 
 		if err := models.CreateEvent(db, event); err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -294,11 +460,17 @@ func registerEventRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		}
 
 		input, ok := bindJSON[struct {
-			Title       string `json:"title"`
-			Description string `json:"description"`
-			Date        string `json:"date"`
-			Link        string `json:"link"`
-			SignUpLink  string `json:"sign_up_link"`
+			Title        string     `json:"title"`
+			Description  string     `json:"description"`
+			Date         string     `json:"date"`
+			StartsAt     *time.Time `json:"starts_at"`
+			EndsAt       *time.Time `json:"ends_at"`
+			Timezone     string     `json:"timezone"`
+			AllDay       *bool      `json:"all_day"`
+			LocationMode string     `json:"location_mode"`
+			Location     string     `json:"location"`
+			Link         string     `json:"link"`
+			SignUpLink   string     `json:"sign_up_link"`
 		}](c)
 		if !ok {
 			return
@@ -311,8 +483,29 @@ func registerEventRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		if input.Description != "" {
 			event.Description = input.Description
 		}
-		if input.Date != "" {
+		if input.Date != "" && input.Date != event.Date {
 			event.Date = input.Date
+			if input.StartsAt == nil {
+				event.StartsAt, event.EndsAt = nil, nil // parsed again from the new date
+			}
+		}
+		if input.StartsAt != nil {
+			event.StartsAt = input.StartsAt
+			event.EndsAt = input.EndsAt
+		} else if input.EndsAt != nil {
+			event.EndsAt = input.EndsAt
+		}
+		if input.Timezone != "" {
+			event.Timezone = input.Timezone
+		}
+		if input.AllDay != nil {
+			event.AllDay = *input.AllDay
+		}
+		if input.LocationMode != "" {
+			event.LocationMode = input.LocationMode
+		}
+		if input.Location != "" {
+			event.Location = input.Location
 		}
 		if input.Link != "" {
 			event.Link = input.Link
@@ -322,12 +515,79 @@ func registerEventRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		}
 
 		if err := models.UpdateEvent(db, event); err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, event)
 	})
 
+	// Confirm a student attended an event, awarding attendance coins (organization only, must own the event)
+	rg.POST("/:id/attendance", func(c *gin.Context) {
+		if !requireRole(c, "organization") {
+			return
+		}
+		org, ok := getUser[models.Organization](c)
+		if !ok {
+			return
+		}
+
+		event, err := models.GetEventByID(db, uintFromParam(c.Param("id")))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+			return
+		}
+		if event.OrganizationID != org.ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you can only record attendance for your own events"})
+			return
+		}
+
+		input, ok := bindJSON[struct {
+			StudentID uint `json:"student_id" binding:"required"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		attendance, err := models.RecordEventAttendance(db, event, input.StudentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, attendance)
+	})
+
+	// List confirmed attendees (organization only, must own the event)
+	rg.GET("/:id/attendance", func(c *gin.Context) {
+		if !requireRole(c, "organization") {
+			return
+		}
+		org, ok := getUser[models.Organization](c)
+		if !ok {
+			return
+		}
+
+		event, err := models.GetEventByID(db, uintFromParam(c.Param("id")))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+			return
+		}
+		if event.OrganizationID != org.ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you can only view attendance for your own events"})
+			return
+		}
+
+		attendance, err := models.GetEventAttendance(db, event.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, attendance)
+	})
+
 	// Delete an event (organization only, must own the event)
 	rg.DELETE("/:id", func(c *gin.Context) {
 		if !requireRole(c, "organization") {
@@ -352,14 +612,26 @@ func registerEventRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 func registerOpportunityRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 	// GET routes first to avoid conflicts
 	rg.GET("/me", func(c *gin.Context) {
-		if !requireRole(c, "professor") {
-			return
-		}
-		prof, ok := getUser[models.Professor](c)
-		if !ok {
+		role, _ := c.Get("role")
+
+		var ops []models.Opportunity
+		var err error
+		if role == "professor" {
+			prof, ok := getUser[models.Professor](c)
+			if !ok {
+				return
+			}
+			ops, err = models.GetOpportunitiesByProfessorID(db, prof.ID)
+		} else if role == "organization" {
+			org, ok := getUser[models.Organization](c)
+			if !ok {
+				return
+			}
+			ops, err = models.GetOpportunitiesByOrganizationID(db, org.ID, true)
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only professors and organizations have opportunities"})
 			return
 		}
-		ops, err := models.GetOpportunitiesByProfessorID(db, prof.ID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -373,16 +645,31 @@ func registerOpportunityRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if !requireVisibleOpportunity(c, db, op) {
+			return
+		}
 		c.JSON(http.StatusOK, op)
 	})
 
 	// POST route - use empty string to match without trailing slash
 	rg.POST("", func(c *gin.Context) {
-		if !requireRole(c, "professor") {
-			return
-		}
-		prof, ok := getUser[models.Professor](c)
-		if !ok {
+		role, _ := c.Get("role")
+
+		var ownerID uint
+		if role == "professor" {
+			prof, ok := getUser[models.Professor](c)
+			if !ok {
+				return
+			}
+			ownerID = prof.ID
+		} else if role == "organization" {
+			org, ok := getUser[models.Organization](c)
+			if !ok {
+				return
+			}
+			ownerID = org.ID
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only professors and organizations can post opportunities"})
 			return
 		}
 
@@ -392,28 +679,118 @@ func registerOpportunityRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 			Requirements string `json:"requirements"`
 			Reward       string `json:"reward"`
 			Type         string `json:"type" binding:"required"`
+			Status       string `json:"status"`
 			TagIDs       []uint `json:"tag_ids"`
 		}](c)
 		if !ok {
 			return
 		}
 
-		opportunity, err := models.CreateOpportunity(db, prof.ID, input.Name, input.Details, input.Requirements, input.Reward, input.Type, input.TagIDs)
+		opportunity, err := models.CreateOpportunity(db, role.(string), ownerID, input.Name, input.Details, input.Requirements, input.Reward, input.Type, input.Status, input.TagIDs)
 		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) || errors.Is(err, models.ErrUnknownTag) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
+		// Alert students whose saved searches match
+		if opportunity.PublishedAt != nil {
+			go models.NotifySavedSearchMatches(db, opportunity)
+		}
+
 		c.JSON(http.StatusCreated, opportunity)
 	})
 
 	// PUT / DELETE
 	rg.PUT("/:id", updateOrDeleteOpportunity(db, "update"))
 	rg.DELETE("/:id", updateOrDeleteOpportunity(db, "delete"))
-}
 
-func updateOrDeleteOpportunity(db *gorm.DB, action string) gin.HandlerFunc {
-	return func(c *gin.Context) {
+	// GET /:id/collaborators - List co-supervisors (any collaborator)
+	rg.GET("/:id/collaborators", func(c *gin.Context) {
+		if !requireRole(c, "professor") {
+			return
+		}
+		prof, ok := getUser[models.Professor](c)
+		if !ok {
+			return
+		}
+		opportunityID := uintFromParam(c.Param("id"))
+		if !requireCollaboratorRole(c, db, opportunityID, prof.ID, models.CollaboratorViewer) {
+			return
+		}
+
+		collaborators, err := models.GetCollaborators(db, opportunityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, collaborators)
+	})
+
+	// POST /:id/collaborators - Add a co-supervisor (owner only)
+	rg.POST("/:id/collaborators", func(c *gin.Context) {
+		if !requireRole(c, "professor") {
+			return
+		}
+		prof, ok := getUser[models.Professor](c)
+		if !ok {
+			return
+		}
+		opportunityID := uintFromParam(c.Param("id"))
+		if !requireCollaboratorRole(c, db, opportunityID, prof.ID, models.CollaboratorOwner) {
+			return
+		}
+
+		input, ok := bindJSON[struct {
+			ProfessorID uint   `json:"professor_id" binding:"required"`
+			Role        string `json:"role" binding:"required"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		collaborator, err := models.AddCollaborator(db, opportunityID, input.ProfessorID, input.Role)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, collaborator)
+	})
+
+	// PUT /:id/collaborators/:professorId - Change a collaborator's role (owner only)
+	rg.PUT("/:id/collaborators/:professorId", func(c *gin.Context) {
+		if !requireRole(c, "professor") {
+			return
+		}
+		prof, ok := getUser[models.Professor](c)
+		if !ok {
+			return
+		}
+		opportunityID := uintFromParam(c.Param("id"))
+		if !requireCollaboratorRole(c, db, opportunityID, prof.ID, models.CollaboratorOwner) {
+			return
+		}
+
+		input, ok := bindJSON[struct {
+			Role string `json:"role" binding:"required"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		collaborator, err := models.UpdateCollaboratorRole(db, opportunityID, uintFromParam(c.Param("professorId")), input.Role)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, collaborator)
+	})
+
+	// DELETE /:id/collaborators/:professorId - Remove a collaborator (owner only, or a collaborator leaving)
+	rg.DELETE("/:id/collaborators/:professorId", func(c *gin.Context) {
 		if !requireRole(c, "professor") {
 			return
 		}
@@ -421,14 +798,33 @@ func updateOrDeleteOpportunity(db *gorm.DB, action string) gin.HandlerFunc {
 		if !ok {
 			return
 		}
+		opportunityID := uintFromParam(c.Param("id"))
+		professorID := uintFromParam(c.Param("professorId"))
+		if professorID != prof.ID && !requireCollaboratorRole(c, db, opportunityID, prof.ID, models.CollaboratorOwner) {
+			return
+		}
+
+		if err := models.RemoveCollaborator(db, opportunityID, professorID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "collaborator removed"})
+	})
+}
+
+func updateOrDeleteOpportunity(db *gorm.DB, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
 		id := uintFromParam(c.Param("id"))
 		op, err := models.GetOpportunityByID(db, id)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		if op.ProfessorID != prof.ID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "cannot modify opportunities you don't own"})
+		minRole := models.CollaboratorEditor
+		if action == "delete" {
+			minRole = models.CollaboratorOwner
+		}
+		if !requireOpportunityAccess(c, db, op, minRole) {
 			return
 		}
 
@@ -438,11 +834,20 @@ func updateOrDeleteOpportunity(db *gorm.DB, action string) gin.HandlerFunc {
 			if !ok {
 				return
 			}
+			wasPublished := op.PublishedAt != nil
 			updated, err := models.UpdateOpportunity(db, id, *updates)
+			if errors.Is(err, models.ErrUnknownTag) || errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
+			// Alert students whose saved searches match a draft that was just published
+			if !wasPublished && updated.PublishedAt != nil {
+				go models.NotifySavedSearchMatches(db, updated)
+			}
 			c.JSON(http.StatusOK, updated)
 		case "delete":
 			if err := models.DeleteOpportunity(db, id); err != nil {
@@ -454,11 +859,11 @@ func updateOrDeleteOpportunity(db *gorm.DB, action string) gin.HandlerFunc {
 	}
 }
 
-// ------------------ APPLICATIONS ------------------
+// ------------------ BOOKMARKS ------------------
 
-func registerApplicationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
-	// POST - Student submits an application
-	rg.POST("", func(c *gin.Context) {
+func registerBookmarkRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// GET - List my bookmarked opportunities (student only)
+	rg.GET("", func(c *gin.Context) {
 		if !requireRole(c, "student") {
 			return
 		}
@@ -466,9 +871,178 @@ func registerApplicationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		if !ok {
 			return
 		}
+		bookmarks, err := models.GetBookmarksByStudentID(db, student.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, bookmarks)
+	})
 
-		input, ok := bindJSON[struct {
-			OpportunityID uint   `json:"opportunity_id" binding:"required"`
+	// POST - Bookmark an opportunity (student only)
+	rg.POST("", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+
+		input, ok := bindJSON[struct {
+			OpportunityID uint `json:"opportunity_id" binding:"required"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		bookmark, err := models.CreateBookmark(db, student.ID, input.OpportunityID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, bookmark)
+	})
+
+	// DELETE /:opportunityId - Remove a bookmark (student only)
+	rg.DELETE("/:opportunityId", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+
+		if err := models.DeleteBookmark(db, student.ID, uintFromParam(c.Param("opportunityId"))); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "bookmark deleted"})
+	})
+}
+
+// ------------------ SAVED SEARCHES ------------------
+
+func registerSavedSearchRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// GET - List my saved searches (student only)
+	rg.GET("", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+		searches, err := models.GetSavedSearchesByStudentID(db, student.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, searches)
+	})
+
+	// GET /:id - Get one of my saved searches (student only)
+	rg.GET("/:id", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+		search, err := models.GetSavedSearchByID(db, uintFromParam(c.Param("id")), student.ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, search)
+	})
+
+	// POST - Save a search, e.g. {"name": "NLP research", "type": "research", "tag_ids": [3]} (student only)
+	rg.POST("", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+		input, ok := bindJSON[models.SavedSearchInput](c)
+		if !ok {
+			return
+		}
+
+		search, err := models.CreateSavedSearch(db, student.ID, *input)
+		if errors.Is(err, models.ErrUnknownTag) || errors.Is(err, gorm.ErrInvalidValue) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, search)
+	})
+
+	// PUT /:id - Replace a saved search's filters (student only)
+	rg.PUT("/:id", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+		input, ok := bindJSON[models.SavedSearchInput](c)
+		if !ok {
+			return
+		}
+
+		search, err := models.UpdateSavedSearch(db, uintFromParam(c.Param("id")), student.ID, *input)
+		if errors.Is(err, models.ErrUnknownTag) || errors.Is(err, gorm.ErrInvalidValue) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, search)
+	})
+
+	// DELETE /:id - Delete a saved search (student only)
+	rg.DELETE("/:id", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+		if err := models.DeleteSavedSearch(db, uintFromParam(c.Param("id")), student.ID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "saved search deleted"})
+	})
+}
+
+// ------------------ APPLICATIONS ------------------
+
+func registerApplicationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// POST - Student submits an application
+	rg.POST("", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+
+		input, ok := bindJSON[struct {
+			OpportunityID uint   `json:"opportunity_id" binding:"required"`
 			Message       string `json:"message"`
 			ResumeLink    string `json:"resume_link"`
 		}](c)
@@ -476,15 +1050,19 @@ func registerApplicationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 			return
 		}
 
-		// Check if opportunity exists
-		_, err := models.GetOpportunityByID(db, input.OpportunityID)
-		if err != nil {
+		// Check if opportunity exists; drafts are hidden from students
+		op, err := models.GetOpportunityByID(db, input.OpportunityID)
+		if err != nil || op.Status == models.OpportunityDraft {
 			c.JSON(http.StatusNotFound, gin.H{"error": "opportunity not found"})
 			return
 		}
 
 		// Create application with message and resume link
 		if err := student.CreateApplication(db, input.OpportunityID, input.Message, input.ResumeLink); err != nil {
+			if errors.Is(err, models.ErrOpportunityNotOpen) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -492,7 +1070,7 @@ func registerApplicationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		c.JSON(http.StatusCreated, gin.H{"message": "application submitted successfully"})
 	})
 
-	// GET /me - Get applications (student: their apps, professor: apps for their opportunities)
+	// GET /me - Get applications (student: their apps, professor/organization: apps for their opportunities)
 	rg.GET("/me", func(c *gin.Context) {
 		role, _ := c.Get("role")
 
@@ -518,31 +1096,33 @@ func registerApplicationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 				return
 			}
 			c.JSON(http.StatusOK, apps)
+		} else if role == "organization" {
+			org, ok := getUser[models.Organization](c)
+			if !ok {
+				return
+			}
+			apps, err := models.GetApplicationsByOrganizationOpportunities(db, org.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, apps)
 		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only students and professors can access applications"})
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid role"})
 		}
 	})
 
-	// PUT /:id/status - Professor updates application status
+	// PUT /:id/status - Opportunity owner (professor editor or organization) updates application status
 	rg.PUT("/:id/status", func(c *gin.Context) {
-		if !requireRole(c, "professor") {
-			return
-		}
-		prof, ok := getUser[models.Professor](c)
-		if !ok {
-			return
-		}
-
 		appID := uintFromParam(c.Param("id"))
 		app, err := models.GetApplicationByID(db, appID)
-		if err != nil {
+		if err != nil || app.Opportunity == nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "application not found"})
 			return
 		}
 
-		// Verify professor owns the opportunity
-		if app.Opportunity.ProfessorID != prof.ID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "cannot modify applications for opportunities you don't own"})
+		// Verify the caller can decide on the opportunity's applications
+		if !requireOpportunityAccess(c, db, app.Opportunity, models.CollaboratorEditor) {
 			return
 		}
 
@@ -563,16 +1143,12 @@ func registerApplicationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 	})
 
 	rg.GET("/opportunity/:id", func(c *gin.Context) {
-		if !requireRole(c, "professor") {
-			return
-		}
-		prof, ok := getUser[models.Professor](c)
-		if !ok {
+		op, err := models.GetOpportunityByID(db, uintFromParam(c.Param("id")))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
-		op, err := models.GetOpportunityByID(db, uintFromParam(c.Param("id")))
-		if err != nil || op.ProfessorID != prof.ID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "cannot view applications"})
+		if !requireOpportunityAccess(c, db, op, models.CollaboratorViewer) {
 			return
 		}
 		apps, err := models.GetApplicationsByOpportunityID(db, op.ID)
@@ -622,106 +1198,78 @@ func registerCoinRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		c.JSON(http.StatusOK, coins)
 	})
 
-	rg.PUT("/increment", func(c *gin.Context) {
-		if !requireRole(c, "student") {
-			return
-		}
-		student, ok := getUser[models.Student](c)
-		if !ok {
-			return
-		}
-		req, ok := bindJSON[struct {
-			Amount int `json:"amount"`
-		}](c)
-		if !ok {
-			return
-		}
-
-		if err := models.IncrementCoins(db, student.ID, req.Amount); err != nil {
+	// GET /rules - Coin reward rules
+	rg.GET("/rules", func(c *gin.Context) {
+		rules, err := models.GetCoinRules(db)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"message": "coins incremented"})
+		c.JSON(http.StatusOK, rules)
 	})
-}
-
-// ------------------ REPORTS ------------------
 
-func registerReportRoutes(rg *gin.RouterGroup, db *gorm.DB) {
-	// POST - Create new report (student only)
-	rg.POST("", func(c *gin.Context) {
-		if !requireRole(c, "student") {
-			return
-		}
-		student, ok := getUser[models.Student](c)
-		if !ok {
+	// PUT /rules/:event - Change a reward amount or disable it (admin only)
+	rg.PUT("/rules/:event", func(c *gin.Context) {
+		if !requireAdmin(c) {
 			return
 		}
-
 		input, ok := bindJSON[struct {
-			RecipientID uint   `json:"recipient_id" binding:"required"`
-			DriveLink   string `json:"drive_link" binding:"required"`
+			Amount  *int  `json:"amount"`
+			Enabled *bool `json:"enabled"`
 		}](c)
 		if !ok {
 			return
 		}
 
-		report, err := models.CreateReport(db, student.ID, input.RecipientID, input.DriveLink)
+		rule, err := models.UpdateCoinRule(db, c.Param("event"), input.Amount, input.Enabled)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-
-		c.JSON(http.StatusCreated, report)
+		c.JSON(http.StatusOK, rule)
 	})
 
-	// GET /me - Get reports (student: their reports, professor: reports to them)
-	rg.GET("/me", func(c *gin.Context) {
+	// POST /awards - Discretionary award to a student (professor or organization, capped per 30 days)
+	rg.POST("/awards", func(c *gin.Context) {
 		role, _ := c.Get("role")
 
-		if role == "student" {
-			student, ok := getUser[models.Student](c)
-			if !ok {
-				return
-			}
-			reports, err := models.GetReportsByStudentID(db, student.ID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-			c.JSON(http.StatusOK, reports)
-		} else if role == "professor" {
+		var granterID uint
+		if role == "professor" {
 			prof, ok := getUser[models.Professor](c)
 			if !ok {
 				return
 			}
-			reports, err := models.GetReportsByRecipientID(db, prof.ID)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			granterID = prof.ID
+		} else if role == "organization" {
+			org, ok := getUser[models.Organization](c)
+			if !ok {
 				return
 			}
-			c.JSON(http.StatusOK, reports)
+			granterID = org.ID
 		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "only students and professors can access reports"})
+			c.JSON(http.StatusForbidden, gin.H{"error": "only professors and organizations can award coins"})
+			return
 		}
-	})
 
-	// GET /student/:id - Get reports by student (professor only)
-	rg.GET("/student/:id", func(c *gin.Context) {
-		if !requireRole(c, "professor") {
+		input, ok := bindJSON[struct {
+			StudentID uint   `json:"student_id" binding:"required"`
+			Amount    int    `json:"amount" binding:"required"`
+			Reason    string `json:"reason" binding:"required"`
+		}](c)
+		if !ok {
 			return
 		}
-		studentID := uintFromParam(c.Param("id"))
-		reports, err := models.GetReportsByStudentID(db, studentID)
+
+		transaction, err := models.GrantDiscretionaryCoins(db, role.(string), granterID, input.StudentID, input.Amount, input.Reason)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, reports)
+		c.JSON(http.StatusCreated, transaction)
 	})
 
-	// DELETE /:id - Delete report (student only, own reports)
-	rg.DELETE("/:id", func(c *gin.Context) {
+	// GET /me/transactions - My coin ledger, newest first (?limit=50&before=<id> for the next page)
+	rg.GET("/me/transactions", func(c *gin.Context) {
 		if !requireRole(c, "student") {
 			return
 		}
@@ -729,112 +1277,760 @@ func registerReportRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		if !ok {
 			return
 		}
-		reportID := uintFromParam(c.Param("id"))
 
-		if err := models.DeleteReport(db, reportID, student.ID); err != nil {
-			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			limit = 50
+		}
+		transactions, err := models.GetCoinTransactionsByStudentID(db, student.ID, uintFromParam(c.Query("before")), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"message": "report deleted"})
+		c.JSON(http.StatusOK, transactions)
 	})
 }
 
-// ------------------ NOTIFICATIONS ------------------
-
-func registerNotificationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
-	// GET /me - Get my notifications
-	rg.GET("/me", func(c *gin.Context) {
-		role, _ := c.Get("role")
-		var recipientID uint
-		var recipientRole string
+// ------------------ REWARDS ------------------
 
-		if role == "student" {
-			student, ok := getUser[models.Student](c)
-			if !ok {
-				return
-			}
-			recipientID = student.ID
-			recipientRole = "student"
-		} else if role == "professor" {
-			prof, ok := getUser[models.Professor](c)
-			if !ok {
-				return
-			}
-			recipientID = prof.ID
-			recipientRole = "professor"
-		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "invalid role"})
-			return
+// Resolve who issues rewards: the current organization, or nil for an admin.
+// Organizations always act for themselves even if their email is also an admin address.
+func rewardIssuer(c *gin.Context) (*uint, bool) {
+	role, _ := c.Get("role")
+	if role == "organization" {
+		org, ok := getUser[models.Organization](c)
+		if !ok {
+			return nil, false
 		}
+		return &org.ID, true
+	}
+	if c.GetBool("is_admin") {
+		return nil, true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "only organizations and admins can manage rewards"})
+	return nil, false
+}
 
-		// Check for unread_only query param
-		unreadOnly := c.Query("unread_only") == "true"
-
-		notifications, err := models.GetNotificationsByRecipient(db, recipientID, recipientRole, unreadOnly)
+// Require the current user to be the issuer of a reward
+func requireRewardIssuer(c *gin.Context, reward *models.Reward) bool {
+	issuerID, ok := rewardIssuer(c)
+	if !ok {
+		return false
+	}
+	if (issuerID == nil) != (reward.OrganizationID == nil) || (issuerID != nil && *issuerID != *reward.OrganizationID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "you can only manage rewards you issued"})
+		return false
+	}
+	return true
+}
+
+func registerRewardRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// GET - Catalog of rewards currently available
+	rg.GET("", func(c *gin.Context) {
+		rewards, err := models.GetActiveRewards(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rewards)
+	})
+
+	// GET /issued - Every reward the current organization or admin published, including sold out ones
+	rg.GET("/issued", func(c *gin.Context) {
+		issuerID, ok := rewardIssuer(c)
+		if !ok {
+			return
+		}
+		rewards, err := models.GetRewardsByIssuer(db, issuerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, rewards)
+	})
+
+	// POST - Publish a reward (organization or admin)
+	rg.POST("", func(c *gin.Context) {
+		issuerID, ok := rewardIssuer(c)
+		if !ok {
+			return
+		}
+		input, ok := bindJSON[models.RewardInput](c)
+		if !ok {
+			return
+		}
+
+		reward, err := models.CreateReward(db, issuerID, *input)
 		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, notifications)
-	})
+		c.JSON(http.StatusCreated, reward)
+	})
+
+	// PUT /:id - Update price, stock or availability (issuer only)
+	rg.PUT("/:id", func(c *gin.Context) {
+		reward, err := models.GetRewardByID(db, uintFromParam(c.Param("id")))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if !requireRewardIssuer(c, reward) {
+			return
+		}
+		input, ok := bindJSON[models.RewardInput](c)
+		if !ok {
+			return
+		}
+
+		reward, err = models.UpdateReward(db, reward, *input)
+		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, reward)
+	})
+
+	// DELETE /:id - Remove a reward from the catalog (issuer only)
+	rg.DELETE("/:id", func(c *gin.Context) {
+		reward, err := models.GetRewardByID(db, uintFromParam(c.Param("id")))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if !requireRewardIssuer(c, reward) {
+			return
+		}
+		if err := models.DeleteReward(db, reward.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "reward deleted"})
+	})
+
+	// POST /:id/redeem - Spend coins on a reward and receive a voucher (student only)
+	rg.POST("/:id/redeem", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+
+		redemption, err := models.RedeemReward(db, uintFromParam(c.Param("id")), student.ID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, redemption)
+	})
+
+	// GET /redemptions/me - My vouchers (student only)
+	rg.GET("/redemptions/me", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+		redemptions, err := models.GetRedemptionsByStudentID(db, student.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, redemptions)
+	})
+
+	// GET /vouchers/:code - Verify a voucher (issuer of the reward only)
+	rg.GET("/vouchers/:code", func(c *gin.Context) {
+		redemption, err := models.GetRedemptionByVoucherCode(db, c.Param("code"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if !requireRewardIssuer(c, redemption.Reward) {
+			return
+		}
+		c.JSON(http.StatusOK, redemption)
+	})
+
+	// POST /vouchers/:code/use - Mark a voucher as consumed (issuer of the reward only)
+	rg.POST("/vouchers/:code/use", func(c *gin.Context) {
+		redemption, err := models.GetRedemptionByVoucherCode(db, c.Param("code"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if !requireRewardIssuer(c, redemption.Reward) {
+			return
+		}
+		if err := models.UseVoucher(db, redemption); err != nil {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, redemption)
+	})
+}
+
+// ------------------ REPORTS ------------------
+
+// Load the report in :id if the current user is its author (student) or recipient (professor)
+func getReportAsParticipant(c *gin.Context, db *gorm.DB) (*models.WeeklyReport, string, uint, bool) {
+	report, err := models.GetReportByID(db, uintFromParam(c.Param("id")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, "", 0, false
+	}
+
+	role, _ := c.Get("role")
+	switch role {
+	case "student":
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return nil, "", 0, false
+		}
+		if report.StudentID == student.ID {
+			return report, "student", student.ID, true
+		}
+	case "professor":
+		prof, ok := getUser[models.Professor](c)
+		if !ok {
+			return nil, "", 0, false
+		}
+		if report.RecipientID == prof.ID {
+			return report, "professor", prof.ID, true
+		}
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "you can only access reports you wrote or received"})
+	return nil, "", 0, false
+}
+
+func registerReportRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// POST - Create new report (student only)
+	rg.POST("", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+
+		input, ok := bindJSON[models.ReportInput](c)
+		if !ok {
+			return
+		}
+
+		report, err := models.CreateReport(db, student.ID, *input)
+		if err != nil {
+			if errors.Is(err, models.ErrDuplicateReport) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, models.ErrNotSupervisor) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, report)
+	})
+
+	// GET /me - Get reports (student: their reports, professor: reports to them)
+	rg.GET("/me", func(c *gin.Context) {
+		role, _ := c.Get("role")
+
+		if role == "student" {
+			student, ok := getUser[models.Student](c)
+			if !ok {
+				return
+			}
+			reports, err := models.GetReportsByStudentID(db, student.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, reports)
+		} else if role == "professor" {
+			prof, ok := getUser[models.Professor](c)
+			if !ok {
+				return
+			}
+			reports, err := models.GetReportsByRecipientID(db, prof.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, reports)
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only students and professors can access reports"})
+		}
+	})
+
+	// GET /supervisors - Professors I can send reports to (student only)
+	rg.GET("/supervisors", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+		professors, err := models.GetSupervisorsByStudentID(db, student.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, professors)
+	})
+
+	// GET /students - Students I supervise (professor only)
+	rg.GET("/students", func(c *gin.Context) {
+		if !requireRole(c, "professor") {
+			return
+		}
+		prof, ok := getUser[models.Professor](c)
+		if !ok {
+			return
+		}
+		students, err := models.GetSupervisedStudents(db, prof.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, students)
+	})
+
+	// GET /student/:id - Get reports by a student I supervise (professor only)
+	rg.GET("/student/:id", func(c *gin.Context) {
+		if !requireRole(c, "professor") {
+			return
+		}
+		prof, ok := getUser[models.Professor](c)
+		if !ok {
+			return
+		}
+		studentID := uintFromParam(c.Param("id"))
+
+		supervised, err := models.IsSupervisor(db, prof.ID, studentID, nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !supervised {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you can only read reports from students you supervise"})
+			return
+		}
+
+		reports, err := models.GetReportsForSupervisor(db, prof.ID, studentID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, reports)
+	})
+
+	// PUT /:id - Revise a report that is not yet approved (student only, own reports)
+	rg.PUT("/:id", func(c *gin.Context) {
+		report, role, _, ok := getReportAsParticipant(c, db)
+		if !ok {
+			return
+		}
+		if role != "student" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the author can revise a report"})
+			return
+		}
+		input, ok := bindJSON[models.ReportContent](c)
+		if !ok {
+			return
+		}
+
+		report, err := models.ReviseReport(db, report, *input)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	})
+
+	// PUT /:id/review - Approve a report or send it back for revision (recipient professor only)
+	rg.PUT("/:id/review", func(c *gin.Context) {
+		report, role, userID, ok := getReportAsParticipant(c, db)
+		if !ok {
+			return
+		}
+		if role != "professor" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the recipient professor can review a report"})
+			return
+		}
+		input, ok := bindJSON[struct {
+			Status  string `json:"status" binding:"required"`
+			Comment string `json:"comment"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		report, err := models.ReviewReport(db, report, userID, input.Status, input.Comment)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	})
+
+	// GET /:id/comments - Feedback thread (author or recipient)
+	rg.GET("/:id/comments", func(c *gin.Context) {
+		report, _, _, ok := getReportAsParticipant(c, db)
+		if !ok {
+			return
+		}
+		comments, err := models.GetReportComments(db, report.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, comments)
+	})
+
+	// POST /:id/comments - Add to the feedback thread (author or recipient)
+	rg.POST("/:id/comments", func(c *gin.Context) {
+		report, role, userID, ok := getReportAsParticipant(c, db)
+		if !ok {
+			return
+		}
+		input, ok := bindJSON[struct {
+			Body string `json:"body" binding:"required"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		comment, err := models.AddReportComment(db, report, role, userID, input.Body)
+		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, comment)
+	})
+
+	// DELETE /:id - Delete report (student only, own reports)
+	rg.DELETE("/:id", func(c *gin.Context) {
+		if !requireRole(c, "student") {
+			return
+		}
+		student, ok := getUser[models.Student](c)
+		if !ok {
+			return
+		}
+		reportID := uintFromParam(c.Param("id"))
+
+		if err := models.DeleteReport(db, reportID, student.ID); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "report deleted"})
+	})
+}
+
+// ------------------ NOTIFICATIONS ------------------
+
+// Resolve the current user as a notification recipient, whatever their account type
+func currentRecipient(c *gin.Context) (uint, string, bool) {
+	role, _ := c.Get("role")
+	switch role {
+	case "student":
+		if student, ok := getUser[models.Student](c); ok {
+			return student.ID, "student", true
+		}
+	case "professor":
+		if prof, ok := getUser[models.Professor](c); ok {
+			return prof.ID, "professor", true
+		}
+	case "organization":
+		if org, ok := getUser[models.Organization](c); ok {
+			return org.ID, "organization", true
+		}
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid role"})
+	}
+	return 0, "", false
+}
+
+// currentEmail returns the logged-in user's email address
+func currentEmail(c *gin.Context) (string, bool) {
+	user, _ := c.Get("user")
+	switch u := user.(type) {
+	case *models.Student:
+		return u.Email, true
+	case *models.Professor:
+		return u.Email, true
+	case *models.Organization:
+		return u.Email, true
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+	return "", false
+}
+
+func registerNotificationRoutes(rg *gin.RouterGroup, db *gorm.DB, hub *realtime.Hub) {
+	// GET /me - Get my notifications, newest first.
+	// ?unread_only=true&type=new_report,report_review&since=2026-01-01&until=2026-02-01&limit=50&before=<next_before>
+	// Returns {items, next_before}; pass next_before back as ?before= for the next page.
+	rg.GET("/me", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
+			return
+		}
+
+		filter := models.NotificationFilter{
+			UnreadOnly: c.Query("unread_only") == "true",
+		}
+		if before := c.Query("before"); before != "" {
+			cursor, err := models.ParseNotificationCursor(before)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			filter.Before = cursor
+		}
+		if types := c.Query("type"); types != "" {
+			filter.EventTypes = strings.Split(types, ",")
+		}
+		if filter.Since, ok = timeFromQuery(c, "since"); !ok {
+			return
+		}
+		if filter.Until, ok = timeFromQuery(c, "until"); !ok {
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 200 {
+			limit = 50
+		}
+		filter.Limit = limit
+
+		page, err := models.GetNotificationsByRecipient(db, recipientID, recipientRole, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	})
+
+	// GET /me/count - Get unread notification count
+	rg.GET("/me/count", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
+			return
+		}
+
+		count, err := models.GetUnreadNotificationCount(db, recipientID, recipientRole)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"count": count})
+	})
+
+	// POST /stream-ticket - Get a single-use ticket for opening /stream?ticket=, valid for 30 seconds
+	rg.POST("/stream-ticket", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
+			return
+		}
+
+		ticket, expiresAt, err := models.IssueStreamTicket(db, recipientID, recipientRole)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"ticket": ticket, "expires_at": expiresAt})
+	})
+
+	// GET /stream - Server-Sent Events stream of my new notifications.
+	// Reconnecting clients send Last-Event-ID (or ?last_event_id=) to receive what they missed.
+	// If they missed more than the replay limit they get a "reset" event and should reload their list.
+	rg.GET("/stream", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
+			return
+		}
 
-	// GET /me/count - Get unread notification count
-	rg.GET("/me/count", func(c *gin.Context) {
-		role, _ := c.Get("role")
-		var recipientID uint
-		var recipientRole string
+		// Subscribe before loading the backlog so nothing created in between is lost
+		events, unsubscribe := hub.Subscribe(models.NotificationTopic(recipientRole, recipientID))
+		defer unsubscribe()
 
-		if role == "student" {
-			student, ok := getUser[models.Student](c)
-			if !ok {
+		lastID := uintFromParam(c.GetHeader("Last-Event-ID"))
+		if lastID == 0 {
+			lastID = uintFromParam(c.Query("last_event_id"))
+		}
+		const replayLimit = 100
+		var backlog []models.Notification
+		reset := false
+		if lastID > 0 {
+			var err error
+			backlog, err = models.GetNotificationsAfter(db, recipientID, recipientRole, lastID, replayLimit+1)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 				return
 			}
-			recipientID = student.ID
-			recipientRole = "student"
-		} else if role == "professor" {
-			prof, ok := getUser[models.Professor](c)
-			if !ok {
-				return
+			if len(backlog) > replayLimit {
+				// Too far behind to replay; resume from the newest notification instead
+				if lastID, err = models.GetLatestNotificationID(db, recipientID, recipientRole); err != nil {
+					c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+					return
+				}
+				backlog, reset = nil, true
 			}
-			recipientID = prof.ID
-			recipientRole = "professor"
-		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "invalid role"})
+		}
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // disable proxy buffering
+		c.Status(http.StatusOK)
+		fmt.Fprint(c.Writer, "retry: 5000\n\n")
+
+		if reset {
+			c.Render(-1, sse.Event{Id: strconv.FormatUint(uint64(lastID), 10), Event: "reset", Data: gin.H{"last_event_id": lastID}})
+		}
+		// Live events can repeat the backlog because we subscribed first; remember what it sent
+		replayed := make(map[uint]bool, len(backlog))
+		for _, notification := range backlog {
+			c.Render(-1, sse.Event{Id: strconv.FormatUint(uint64(notification.ID), 10), Event: "notification", Data: notification})
+			replayed[notification.ID] = true
+			lastID = notification.ID
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(25 * time.Second)
+		defer heartbeat.Stop()
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case event, ok := <-events:
+				if !ok {
+					// Dropped for falling behind; the client reconnects and resumes from lastID
+					return false
+				}
+				if replayed[event.ID] {
+					delete(replayed, event.ID)
+					return true
+				}
+				// IDs can commit out of order, so send everything else but keep the resume point at
+				// the highest ID seen so a reconnect does not replay what the client already has
+				lastID = max(lastID, event.ID)
+				c.Render(-1, sse.Event{Id: strconv.FormatUint(uint64(lastID), 10), Event: event.Name, Data: json.RawMessage(event.Data)})
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			return true
+		})
+	})
+
+	// GET /preferences - How each notification type reaches me: in_app, email, digest or none
+	rg.GET("/preferences", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
 			return
 		}
 
-		count, err := models.GetUnreadNotificationCount(db, recipientID, recipientRole)
+		preferences, err := models.GetNotificationPreferences(db, recipientID, recipientRole)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, gin.H{"count": count})
+		c.JSON(http.StatusOK, preferences)
 	})
 
-	// PUT /:id/read - Mark notification as read
-	rg.PUT("/:id/read", func(c *gin.Context) {
-		role, _ := c.Get("role")
-		var recipientID uint
+	// PUT /preferences - Change channels, e.g. {"report_review": "digest"}; omitted types keep their setting
+	rg.PUT("/preferences", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
+			return
+		}
 
-		if role == "student" {
-			student, ok := getUser[models.Student](c)
-			if !ok {
+		input, ok := bindJSON[map[string]string](c)
+		if !ok {
+			return
+		}
+
+		preferences, err := models.UpdateNotificationPreferences(db, recipientID, recipientRole, *input)
+		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
-			recipientID = student.ID
-		} else if role == "professor" {
-			prof, ok := getUser[models.Professor](c)
-			if !ok {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, preferences)
+	})
+
+	// GET /digest - When my digest emails are sent
+	rg.GET("/digest", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
+			return
+		}
+
+		setting, err := models.GetDigestSetting(db, recipientID, recipientRole)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, setting)
+	})
+
+	// PUT /digest - Choose daily or weekly digests and the local time to receive them
+	rg.PUT("/digest", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
+			return
+		}
+
+		input, ok := bindJSON[models.DigestSettingInput](c)
+		if !ok {
+			return
+		}
+
+		setting, err := models.UpdateDigestSetting(db, recipientID, recipientRole, *input)
+		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
-			recipientID = prof.ID
-		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "invalid role"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, setting)
+	})
+
+	// PUT /:id/read - Mark notification as read
+	rg.PUT("/:id/read", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
 			return
 		}
 
 		notificationID := uintFromParam(c.Param("id"))
-		if err := models.MarkNotificationAsRead(db, notificationID, recipientID); err != nil {
+		if err := models.MarkNotificationAsRead(db, notificationID, recipientID, recipientRole); err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -843,26 +2039,8 @@ func registerNotificationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 
 	// PUT /read-all - Mark all notifications as read
 	rg.PUT("/read-all", func(c *gin.Context) {
-		role, _ := c.Get("role")
-		var recipientID uint
-		var recipientRole string
-
-		if role == "student" {
-			student, ok := getUser[models.Student](c)
-			if !ok {
-				return
-			}
-			recipientID = student.ID
-			recipientRole = "student"
-		} else if role == "professor" {
-			prof, ok := getUser[models.Professor](c)
-			if !ok {
-				return
-			}
-			recipientID = prof.ID
-			recipientRole = "professor"
-		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "invalid role"})
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
 			return
 		}
 
@@ -873,30 +2051,48 @@ func registerNotificationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		c.JSON(http.StatusOK, gin.H{"message": "all notifications marked as read"})
 	})
 
+	// DELETE "" - Delete several notifications: {"ids": [1, 2, 3]} or {"read": true} for every read one
+	rg.DELETE("", func(c *gin.Context) {
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
+			return
+		}
+
+		input, ok := bindJSON[struct {
+			IDs  []uint `json:"ids"`
+			Read bool   `json:"read"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		var deleted int64
+		var err error
+		switch {
+		case input.Read:
+			deleted, err = models.DeleteReadNotifications(db, recipientID, recipientRole)
+		case len(input.IDs) > 0:
+			deleted, err = models.DeleteNotifications(db, input.IDs, recipientID, recipientRole)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ids or read is required"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+	})
+
 	// DELETE /:id - Delete notification
 	rg.DELETE("/:id", func(c *gin.Context) {
-		role, _ := c.Get("role")
-		var recipientID uint
-
-		if role == "student" {
-			student, ok := getUser[models.Student](c)
-			if !ok {
-				return
-			}
-			recipientID = student.ID
-		} else if role == "professor" {
-			prof, ok := getUser[models.Professor](c)
-			if !ok {
-				return
-			}
-			recipientID = prof.ID
-		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "invalid role"})
+		recipientID, recipientRole, ok := currentRecipient(c)
+		if !ok {
 			return
 		}
 
 		notificationID := uintFromParam(c.Param("id"))
-		if err := models.DeleteNotification(db, notificationID, recipientID); err != nil {
+		if err := models.DeleteNotification(db, notificationID, recipientID, recipientRole); err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
@@ -916,14 +2112,22 @@ func registerTagRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		input, ok := bindJSON[struct {
 			Name        string `json:"name" binding:"required"`
 			Description string `json:"description"`
+			ParentID    *uint  `json:"parent_id"`
 		}](c)
 		if !ok {
 			return
 		}
 
-		tag, err := models.CreateTag(db, input.Name, input.Description)
+		tag, err := models.CreateTag(db, input.Name, input.Description, input.ParentID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			switch {
+			case errors.Is(err, gorm.ErrInvalidValue):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case errors.Is(err, models.ErrTagExists):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
 			return
 		}
 
@@ -940,4 +2144,223 @@ func registerTagRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		}
 		c.JSON(http.StatusOK, tag)
 	})
+
+	// PUT /:id/parent - Move tag under a parent category (admin only, null parent_id makes it a root tag)
+	rg.PUT("/:id/parent", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		input, ok := bindJSON[struct {
+			ParentID *uint `json:"parent_id"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		tag, err := models.SetTagParent(db, uintFromParam(c.Param("id")), input.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, tag)
+	})
+
+	// POST /:id/aliases - Add an alias that resolves to this tag (admin only)
+	rg.POST("/:id/aliases", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		input, ok := bindJSON[struct {
+			Name string `json:"name" binding:"required"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		alias, err := models.AddTagAlias(db, uintFromParam(c.Param("id")), input.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, alias)
+	})
+
+	// DELETE /aliases/:id - Remove an alias (admin only)
+	rg.DELETE("/aliases/:id", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		if err := models.DeleteTagAlias(db, uintFromParam(c.Param("id"))); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "alias deleted"})
+	})
+
+	// POST /:id/merge - Merge this tag into another one, moving all associations (admin only)
+	rg.POST("/:id/merge", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		input, ok := bindJSON[struct {
+			TargetID uint `json:"target_id" binding:"required"`
+		}](c)
+		if !ok {
+			return
+		}
+
+		tag, err := models.MergeTags(db, uintFromParam(c.Param("id")), input.TargetID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, tag)
+	})
+}
+
+// ------------------ BACKGROUND JOBS ------------------
+
+func registerJobRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// GET - Background job schedule and last outcome (admin only)
+	rg.GET("", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+		jobs, err := models.GetScheduledJobs(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, jobs)
+	})
+}
+
+// ------------------ EMAIL TEMPLATES ------------------
+
+func registerEmailTemplateRoutes(rg *gin.RouterGroup) {
+	// GET - Available email templates and locales (admin only)
+	rg.GET("", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"templates": mail_service.Templates.Names(),
+			"locales":   mail_service.Locales,
+		})
+	})
+
+	// GET /:name/preview - Render a template with sample data (admin only).
+	// ?locale=ar picks a translation; ?format=html or ?format=text returns the body alone for viewing in a browser.
+	rg.GET("/:name/preview", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		email, err := mail_service.Templates.Preview(c.Param("name"), c.DefaultQuery("locale", mail_service.DefaultLocale))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+
+		switch c.Query("format") {
+		case "html":
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(email.HTML))
+		case "text":
+			c.String(http.StatusOK, email.Text)
+		default:
+			c.JSON(http.StatusOK, email)
+		}
+	})
+}
+
+// ------------------ EMAIL OUTBOX ------------------
+
+func registerEmailOutboxRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// GET - Queue depth by status and this replica's delivery metrics (admin only)
+	rg.GET("", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+		counts, err := models.GetOutboxCounts(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"queue": counts, "metrics": outbox.Snapshot()})
+	})
+
+	// GET /dead - Emails that failed too many times (admin only)
+	rg.GET("/dead", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+		emails, err := models.GetDeadOutboxEmails(db, 100)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, emails)
+	})
+
+	// POST /:id/retry - Queue a dead-lettered email again (admin only)
+	rg.POST("/:id/retry", func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+		if err := models.RetryOutboxEmail(db, uintFromParam(c.Param("id"))); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "email queued for retry"})
+	})
+}
+
+// ------------------ EMAIL DELIVERY STATUS ------------------
+
+func registerEmailStatusRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// GET - Whether emails to my address are being delivered, for the "fix your address" banner
+	rg.GET("", func(c *gin.Context) {
+		email, ok := currentEmail(c)
+		if !ok {
+			return
+		}
+		suppression, err := models.GetEmailSuppression(db, email)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if suppression == nil || suppression.SuppressedAt == nil {
+			c.JSON(http.StatusOK, gin.H{"email": email, "suppressed": false})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"email":         email,
+			"suppressed":    true,
+			"reason":        suppression.LastEvent,
+			"detail":        suppression.LastReason,
+			"suppressed_at": suppression.SuppressedAt,
+			"can_retry":     suppression.Clearable(),
+		})
+	})
+
+	// POST /retry - Resume sending after I fixed my mailbox. Only soft-bounce suppressions can be lifted this way.
+	rg.POST("/retry", func(c *gin.Context) {
+		email, ok := currentEmail(c)
+		if !ok {
+			return
+		}
+		if err := models.ClearEmailSuppression(db, email); err != nil {
+			if errors.Is(err, models.ErrSuppressionPermanent) {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "email delivery resumed"})
+	})
 }
@@ -1,8 +1,15 @@
 package routes
 
 import (
+	"errors"
+	"io"
+	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
+	mail_service "github.com/OmarDardery/solve-the-x-backend/mail_service"
 	"github.com/OmarDardery/solve-the-x-backend/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -14,6 +21,9 @@ func RegisterPublicRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 	registerPublicTagRoutes(rg.Group("/tags"), db)
 	registerPublicEventRoutes(rg.Group("/events"), db)
 	registerPublicOrganizationRoutes(rg.Group("/organizations"), db)
+	registerPublicLeaderboardRoutes(rg.Group("/leaderboard"), db)
+	registerEmailEventRoutes(rg.Group("/email-events"), db)
+	registerUnsubscribeRoutes(rg.Group("/unsubscribe"), db)
 }
 
 // ------------------ PUBLIC OPPORTUNITIES ------------------
@@ -43,8 +53,8 @@ func registerPublicOpportunityRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 	rg.GET("/:id", func(c *gin.Context) {
 		id := uintFromParam(c.Param("id"))
 		opportunity, err := models.GetOpportunityByID(db, id)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if err != nil || opportunity.Status == models.OpportunityDraft {
+			c.JSON(http.StatusNotFound, gin.H{"error": "opportunity not found"})
 			return
 		}
 		c.JSON(http.StatusOK, opportunity)
@@ -74,6 +84,30 @@ func registerPublicTagRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		c.JSON(http.StatusOK, tags)
 	})
 
+	// Autocomplete tags by prefix/trigram match on names and aliases: GET /autocomplete?q=mach&limit=10
+	rg.GET("/autocomplete", func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 || limit > 50 {
+			limit = 10
+		}
+		suggestions, err := models.AutocompleteTags(db, c.Query("q"), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, suggestions)
+	})
+
+	// Usage statistics per tag (open opportunities, students, 30 day trend)
+	rg.GET("/stats", func(c *gin.Context) {
+		stats, err := models.GetTagStats(db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+	})
+
 	// Get specific tag by ID (public)
 	rg.GET("/:id", func(c *gin.Context) {
 		id := uintFromParam(c.Param("id"))
@@ -89,25 +123,32 @@ func registerPublicTagRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 // ------------------ PUBLIC EVENTS ------------------
 
 func registerPublicEventRoutes(rg *gin.RouterGroup, db *gorm.DB) {
-	// Get all events (public endpoint for browsing)
-	rg.GET("", func(c *gin.Context) {
-		events, err := models.GetAllEvents(db)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Get events (public endpoint for browsing)
+	// ?when=upcoming|past&from=2026-03-01&until=2026-04-01 (until is exclusive)
+	listEvents := func(c *gin.Context) {
+		filter := models.EventFilter{When: c.Query("when")}
+		var ok bool
+		if filter.From, ok = timeFromQuery(c, "from"); !ok {
+			return
+		}
+		if filter.Until, ok = timeFromQuery(c, "until"); !ok {
 			return
 		}
-		c.JSON(http.StatusOK, events)
-	})
 
-	// Also handle with trailing slash for compatibility
-	rg.GET("/", func(c *gin.Context) {
-		events, err := models.GetAllEvents(db)
+		events, err := models.GetEvents(db, filter)
 		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, events)
-	})
+	}
+	rg.GET("", listEvents)
+	// Also handle with trailing slash for compatibility
+	rg.GET("/", listEvents)
 
 	// Get specific event by ID (public)
 	rg.GET("/:id", func(c *gin.Context) {
@@ -136,6 +177,17 @@ func registerPublicOrganizationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		c.JSON(http.StatusOK, org)
 	})
 
+	// Get published opportunities by organization ID (public)
+	rg.GET("/:id/opportunities", func(c *gin.Context) {
+		id := uintFromParam(c.Param("id"))
+		opportunities, err := models.GetOpportunitiesByOrganizationID(db, id, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, opportunities)
+	})
+
 	// Get events by organization ID (public)
 	rg.GET("/:id/events", func(c *gin.Context) {
 		id := uintFromParam(c.Param("id"))
@@ -147,3 +199,149 @@ func registerPublicOrganizationRoutes(rg *gin.RouterGroup, db *gorm.DB) {
 		c.JSON(http.StatusOK, events)
 	})
 }
+
+// ------------------ PUBLIC LEADERBOARD ------------------
+
+func registerPublicLeaderboardRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// Ranked students (?window=all_time|semester|month&by=coins|applications|reports&tag=<name>&limit=50)
+	rg.GET("", func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 100 {
+			limit = 50
+		}
+
+		var tag *models.Tag
+		if name := c.Query("tag"); name != "" {
+			tag, err = models.ResolveTag(db, name)
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "tag not found"})
+				return
+			}
+		}
+
+		board, err := models.GetLeaderboard(db, c.DefaultQuery("window", models.LeaderboardAllTime), c.DefaultQuery("by", "coins"), tag, limit)
+		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, board)
+	})
+}
+
+// ------------------ EMAIL PROVIDER WEBHOOKS ------------------
+
+// maxWebhookBody caps webhook payloads; SendGrid batches stay well under this
+const maxWebhookBody = 5 << 20
+
+func registerEmailEventRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// SendGrid Event Webhook, signed with the key in SENDGRID_WEBHOOK_PUBLIC_KEY
+	rg.POST("/sendgrid", func(c *gin.Context) {
+		key := os.Getenv("SENDGRID_WEBHOOK_PUBLIC_KEY")
+		if key == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "webhook not configured"})
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		err = mail_service.VerifySendGridSignature(key,
+			c.GetHeader("X-Twilio-Email-Event-Webhook-Signature"),
+			c.GetHeader("X-Twilio-Email-Event-Webhook-Timestamp"),
+			body, time.Now())
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		events, err := mail_service.ParseSendGridEvents(body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		recordEmailEvents(c, db, "sendgrid", events)
+	})
+
+	// Generic bounce format for other providers and relays, signed with EMAIL_WEBHOOK_SECRET
+	rg.POST("", func(c *gin.Context) {
+		secret := os.Getenv("EMAIL_WEBHOOK_SECRET")
+		if secret == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "webhook not configured"})
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		err = mail_service.VerifyWebhookSignature(secret,
+			c.GetHeader("X-Webhook-Signature"),
+			c.GetHeader("X-Webhook-Timestamp"),
+			body, time.Now())
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		events, err := mail_service.ParseGenericEvents(body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		recordEmailEvents(c, db, "generic", events)
+	})
+}
+
+func recordEmailEvents(c *gin.Context, db *gorm.DB, source string, events []mail_service.DeliveryEvent) {
+	if err := models.RecordEmailEvents(db, source, events); err != nil {
+		// a non-2xx makes the provider retry the batch later
+		log.Printf("email events from %s: %v", source, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recorded": len(events)})
+}
+
+// ------------------ UNSUBSCRIBE ------------------
+
+func registerUnsubscribeRoutes(rg *gin.RouterGroup, db *gorm.DB) {
+	// GET ?token= - What a link unsubscribes from. Changes nothing, since link scanners open every URL in an email.
+	rg.GET("", func(c *gin.Context) {
+		unsubscribe, err := mail_service.ParseUnsubscribeToken(c.Query("token"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"category": unsubscribe.Category, "role": unsubscribe.RecipientRole})
+	})
+
+	// POST ?token= - Stop those emails. Also the target of one-click unsubscribes from mail clients (RFC 8058),
+	// which post List-Unsubscribe=One-Click as a form.
+	rg.POST("", func(c *gin.Context) {
+		token := c.Query("token")
+		if token == "" {
+			token = c.PostForm("token")
+		}
+		unsubscribe, err := mail_service.ParseUnsubscribeToken(token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		err = models.UnsubscribeFromEmail(db, unsubscribe.RecipientID, unsubscribe.RecipientRole, unsubscribe.Category)
+		if err != nil {
+			if errors.Is(err, gorm.ErrInvalidValue) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "unsubscribed", "category": unsubscribe.Category})
+	})
+}
@@ -10,6 +10,7 @@ import { Signup } from './pages/Signup'
 import { SelectRole } from './pages/SelectRole'
 import { OrganizationSignup } from './pages/OrganizationSignup'
 import { OrganizationLogin } from './pages/OrganizationLogin'
+import { Unsubscribe } from './pages/Unsubscribe'
 
 // Dashboard Pages
 import { ProfessorDashboard } from './pages/dashboard/ProfessorDashboard'
@@ -36,6 +37,7 @@ function App() {
         <Route path="/login/organization" element={<OrganizationLogin />} />
         <Route path="/signup" element={<Signup />} />
         <Route path="/signup/organization" element={<OrganizationSignup />} />
+        <Route path="/unsubscribe" element={<Unsubscribe />} />
         <Route
           path="/select-role"
           element={
@@ -0,0 +1,57 @@
+import { useEffect, useState } from 'react'
+import toast from 'react-hot-toast'
+import { useAuth } from '../../context/AuthContext'
+import { apiService } from '../../services/api'
+import { Button } from '../ui/Button'
+
+const reasons = {
+  hard_bounce: 'your mail server rejected them',
+  soft_bounce: 'your mailbox kept refusing them',
+  complaint: 'they were reported as spam',
+}
+
+// Tells the user we stopped emailing their address after bounces or complaints. Soft bounces can be
+// retried once their mailbox accepts mail again; the address itself cannot be changed from the app.
+export function EmailStatusBanner() {
+  const { currentUser } = useAuth()
+  const [status, setStatus] = useState(null)
+  const [retrying, setRetrying] = useState(false)
+
+  useEffect(() => {
+    if (!currentUser) return
+    apiService.getEmailStatus().then(setStatus).catch(() => setStatus(null))
+  }, [currentUser])
+
+  if (!status?.suppressed) return null
+
+  const handleRetry = async () => {
+    setRetrying(true)
+    try {
+      await apiService.retryEmailDelivery()
+      setStatus({ ...status, suppressed: false })
+      toast.success('We will email you again')
+    } catch (error) {
+      toast.error(error.message || 'Failed to resume emails')
+    } finally {
+      setRetrying(false)
+    }
+  }
+
+  return (
+    <div className="max-w-7xl mx-auto px-4 sm:px-6 lg:px-8 pt-4">
+      <div className="alert-warning flex flex-col sm:flex-row sm:items-center gap-3" role="alert">
+        <p className="flex-1 text-left">
+          We stopped sending emails to <strong>{status.email}</strong> because {reasons[status.reason] || 'they could not be delivered'}.
+          {status.can_retry
+            ? ' Make sure your mailbox has space and is not blocking us, then let us know.'
+            : ' We will not email this address again. Accounts cannot switch to a new email address yet, so please contact the SolveX team if you need our emails at another address.'}
+        </p>
+        {status.can_retry && (
+          <Button variant="secondary" size="sm" onClick={handleRetry} disabled={retrying}>
+            {retrying ? 'Resuming...' : 'My mailbox is fixed, resume emails'}
+          </Button>
+        )}
+      </div>
+    </div>
+  )
+}
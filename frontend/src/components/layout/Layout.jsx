@@ -1,10 +1,12 @@
 import { Navbar } from './Navbar'
+import { EmailStatusBanner } from './EmailStatusBanner'
 import { Toaster } from 'react-hot-toast'
 
 export function Layout({ children }) {
   return (
     <div className="page-bg">
       <Navbar />
+      <EmailStatusBanner />
       <main className="max-w-7xl mx-auto px-4 sm:px-6 lg:px-8 py-8">
         {children}
       </main>
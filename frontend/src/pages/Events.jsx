@@ -10,9 +10,30 @@ import {
   Building2, 
   ExternalLink, 
   Search,
+  MapPin,
+  Video,
   Link as LinkIcon
 } from 'lucide-react'
 import toast from 'react-hot-toast'
+import { formatEventDate } from '../utils/eventDates'
+
+const whenOptions = [
+  { value: 'upcoming', label: 'Upcoming' },
+  { value: 'past', label: 'Past' },
+  { value: '', label: 'All' },
+]
+
+function EventLocation({ event, className }) {
+  if (!event.location_mode && !event.location) return null
+  const Icon = event.location_mode === 'online' ? Video : MapPin
+  const label = event.location || (event.location_mode === 'online' ? 'Online' : 'In person')
+  return (
+    <p className={className}>
+      <Icon className="w-4 h-4 flex-shrink-0" />
+      <span className="truncate">{label}</span>
+    </p>
+  )
+}
 
 export function Events() {
   const [events, setEvents] = useState([])
@@ -21,10 +42,11 @@ export function Events() {
   const [searchQuery, setSearchQuery] = useState('')
   const [selectedEvent, setSelectedEvent] = useState(null)
   const [showDetailModal, setShowDetailModal] = useState(false)
+  const [when, setWhen] = useState('upcoming')
 
   useEffect(() => {
     fetchEvents()
-  }, [])
+  }, [when])
 
   useEffect(() => {
     if (searchQuery.trim() === '') {
@@ -45,7 +67,7 @@ export function Events() {
   const fetchEvents = async () => {
     try {
       setLoading(true)
-      const response = await apiService.getAllEvents()
+      const response = await apiService.getAllEvents({ when })
       const eventsData = Array.isArray(response) ? response : []
       setEvents(eventsData)
       setFilteredEvents(eventsData)
@@ -97,6 +119,20 @@ export function Events() {
         />
       </div>
 
+      {/* When */}
+      <div className="flex gap-2">
+        {whenOptions.map((option) => (
+          <Button
+            key={option.value}
+            variant={when === option.value ? 'primary' : 'secondary'}
+            size="sm"
+            onClick={() => setWhen(option.value)}
+          >
+            {option.label}
+          </Button>
+        ))}
+      </div>
+
       {/* Events Grid */}
       {filteredEvents.length === 0 ? (
         <Card>
@@ -124,12 +160,13 @@ export function Events() {
             >
               <CardHeader className="pb-3">
                 <CardTitle className="text-lg line-clamp-2">{event.title}</CardTitle>
-                {event.date && (
+                {formatEventDate(event) && (
                   <p className="text-sm text-brand flex items-center gap-1 mt-1">
                     <Calendar className="w-4 h-4" />
-                    {event.date}
+                    {formatEventDate(event)}
                   </p>
                 )}
+                <EventLocation event={event} className="text-sm text-muted flex items-center gap-1 mt-1" />
               </CardHeader>
               <CardContent>
                 {event.description && (
@@ -203,12 +240,15 @@ export function Events() {
         {selectedEvent && (
           <div className="space-y-6">
             {/* Date */}
-            {selectedEvent.date && (
-              <div className="flex items-center gap-2 text-brand">
-                <Calendar className="w-5 h-5" />
-                <span className="font-medium">{selectedEvent.date}</span>
-              </div>
-            )}
+            <div className="space-y-2">
+              {formatEventDate(selectedEvent) && (
+                <div className="flex items-center gap-2 text-brand">
+                  <Calendar className="w-5 h-5" />
+                  <span className="font-medium">{formatEventDate(selectedEvent)}</span>
+                </div>
+              )}
+              <EventLocation event={selectedEvent} className="flex items-center gap-2 text-body" />
+            </div>
 
             {/* Description */}
             {selectedEvent.description && (
@@ -3,6 +3,7 @@ import { useParams, Link } from 'react-router-dom'
 import { apiService } from '../services/api'
 import { Card, CardContent, CardHeader, CardTitle } from '../components/ui/Card'
 import { Button } from '../components/ui/Button'
+import { Badge } from '../components/ui/Badge'
 import { 
   Building2, 
   Calendar, 
@@ -11,14 +12,17 @@ import {
   Globe, 
   ExternalLink,
   ArrowLeft,
+  Briefcase,
   Link as LinkIcon
 } from 'lucide-react'
 import toast from 'react-hot-toast'
+import { formatEventDate } from '../utils/eventDates'
 
 export function OrganizationDetail() {
   const { id } = useParams()
   const [organization, setOrganization] = useState(null)
   const [events, setEvents] = useState([])
+  const [opportunities, setOpportunities] = useState([])
   const [loading, setLoading] = useState(true)
 
   useEffect(() => {
@@ -30,12 +34,14 @@ export function OrganizationDetail() {
   const fetchOrganizationData = async () => {
     try {
       setLoading(true)
-      const [orgData, eventsData] = await Promise.all([
+      const [orgData, eventsData, opportunitiesData] = await Promise.all([
         apiService.getOrganizationById(id),
         apiService.getEventsByOrganizationId(id),
+        apiService.getOpportunitiesByOrganizationId(id),
       ])
       setOrganization(orgData)
       setEvents(Array.isArray(eventsData) ? eventsData : [])
+      setOpportunities(Array.isArray(opportunitiesData) ? opportunitiesData : [])
     } catch (error) {
       console.error('Error fetching organization:', error)
       toast.error('Failed to load organization details')
@@ -151,6 +157,46 @@ export function OrganizationDetail() {
         </CardContent>
       </Card>
 
+      {/* Opportunities by Organization */}
+      <Card>
+        <CardHeader>
+          <CardTitle className="flex items-center gap-2">
+            <Briefcase className="w-5 h-5 icon-primary" />
+            Opportunities by {organization.name}
+          </CardTitle>
+        </CardHeader>
+        <CardContent>
+          {opportunities.length === 0 ? (
+            <div className="text-center py-12">
+              <Briefcase className="w-16 h-16 icon-muted mx-auto mb-4" />
+              <h3 className="text-lg font-medium text-heading mb-2">No Opportunities Yet</h3>
+              <p className="text-muted">This organization hasn't posted any opportunities yet.</p>
+            </div>
+          ) : (
+            <div className="grid grid-cols-1 md:grid-cols-2 gap-4">
+              {opportunities.map((opportunity) => (
+                <Link
+                  key={opportunity.ID}
+                  to={`/opportunities/${opportunity.ID}`}
+                  className="block p-4 border rounded-lg border-default hover:shadow-md transition-shadow"
+                >
+                  <div className="flex items-start justify-between gap-2">
+                    <h3 className="font-semibold text-heading text-lg">{opportunity.name}</h3>
+                    <Badge>{opportunity.type}</Badge>
+                  </div>
+                  {opportunity.details && (
+                    <p className="text-body text-sm mt-2 line-clamp-2">{opportunity.details}</p>
+                  )}
+                  {opportunity.status === 'closed' && (
+                    <p className="text-xs text-muted mt-2">Closed</p>
+                  )}
+                </Link>
+              ))}
+            </div>
+          )}
+        </CardContent>
+      </Card>
+
       {/* Events by Organization */}
       <Card>
         <CardHeader>
@@ -174,10 +220,10 @@ export function OrganizationDetail() {
                   className="p-4 border rounded-lg border-default hover:shadow-md transition-shadow"
                 >
                   <h3 className="font-semibold text-heading text-lg">{event.title}</h3>
-                  {event.date && (
+                  {formatEventDate(event) && (
                     <p className="text-sm text-brand flex items-center gap-1 mt-1">
                       <Calendar className="w-4 h-4" />
-                      {event.date}
+                      {formatEventDate(event)}
                     </p>
                   )}
                   {event.description && (
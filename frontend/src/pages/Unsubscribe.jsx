@@ -0,0 +1,95 @@
+import { useEffect, useState } from 'react'
+import { Link, useSearchParams } from 'react-router-dom'
+import { useTheme } from '../context/ThemeContext'
+import { Button } from '../components/ui/Button'
+import { Card, CardContent, CardHeader, CardTitle, CardDescription } from '../components/ui/Card'
+import { ThemeToggle } from '../components/ui/ThemeToggle'
+import { apiService } from '../services/api'
+import { Mail } from 'lucide-react'
+
+const categories = {
+  digest: 'your notification digests',
+  application_status: 'application status updates',
+  new_application: 'new application alerts',
+  new_report: 'weekly report submissions',
+  report_review: 'report reviews',
+  report_comment: 'report discussions',
+  report_reminder: 'weekly report reminders',
+  overdue_reports: 'overdue report summaries',
+  coins_awarded: 'coin awards',
+  collaborator_added: 'collaboration invites',
+  saved_search_match: 'saved search alerts',
+  reward_redeemed: 'reward redemptions',
+}
+
+// Landing page for the unsubscribe link in email footers. Nothing changes until the user confirms,
+// since link scanners open every URL in an email.
+export function Unsubscribe() {
+  const [searchParams] = useSearchParams()
+  const token = searchParams.get('token') || ''
+  const { getLogo } = useTheme()
+  const [category, setCategory] = useState(null)
+  const [state, setState] = useState('loading') // loading | confirm | done | error
+  const [errorMessage, setErrorMessage] = useState('')
+
+  useEffect(() => {
+    apiService.getUnsubscribe(token)
+      .then((data) => {
+        setCategory(data.category)
+        setState('confirm')
+      })
+      .catch(() => {
+        setErrorMessage('This unsubscribe link is invalid or incomplete.')
+        setState('error')
+      })
+  }, [token])
+
+  const handleConfirm = async () => {
+    setState('loading')
+    try {
+      await apiService.unsubscribe(token)
+      setState('done')
+    } catch (error) {
+      setErrorMessage(error.message || 'Failed to unsubscribe')
+      setState('error')
+    }
+  }
+
+  const label = categories[category] || 'these emails'
+
+  return (
+    <div className="auth-bg flex items-center justify-center px-4 py-12 relative">
+      <div className="absolute top-4 right-4">
+        <ThemeToggle />
+      </div>
+      <Card className="w-full max-w-md">
+        <CardHeader className="text-center">
+          <div className="mx-auto w-20 h-20 mb-4">
+            <img src={getLogo()} alt="SolveX" className="w-full h-full object-contain" />
+          </div>
+          <div className="mx-auto w-12 h-12 bg-brand-light rounded-full flex items-center justify-center mb-4">
+            <Mail className="w-6 h-6 icon-primary" />
+          </div>
+          <CardTitle className="text-2xl">Unsubscribe</CardTitle>
+          <CardDescription>
+            {state === 'done'
+              ? `You will no longer receive ${label} by email. You will still see them in the app.`
+              : state === 'error'
+                ? errorMessage
+                : `Stop receiving ${label} by email?`}
+          </CardDescription>
+        </CardHeader>
+        <CardContent className="space-y-3">
+          {(state === 'confirm' || state === 'loading') && (
+            <Button className="w-full" onClick={handleConfirm} disabled={state === 'loading'}>
+              {state === 'loading' ? 'Please wait...' : 'Unsubscribe'}
+            </Button>
+          )}
+          <p className="text-center text-sm">
+            <Link to="/" className="text-brand font-medium">Back to SolveX</Link>
+          </p>
+        </CardContent>
+      </Card>
+    </div>
+  )
+}
@@ -3,7 +3,7 @@ import { useAuth } from '../context/AuthContext'
 import { apiService } from '../services/api'
 import { Card, CardContent, CardHeader, CardTitle } from '../components/ui/Card'
 import { Button } from '../components/ui/Button'
-import { Input } from '../components/ui/Input'
+import { Input, Textarea, Select } from '../components/ui/Input'
 import { Badge } from '../components/ui/Badge'
 import { Modal } from '../components/ui/Modal'
 import { validateDriveLink, formatDriveLink } from '../utils/validateDriveLink'
@@ -16,7 +16,12 @@ export function WeeklyReports() {
   const [loading, setLoading] = useState(true)
   const [showSubmitModal, setShowSubmitModal] = useState(false)
   const [recipientId, setRecipientId] = useState('')
+  const [supervisors, setSupervisors] = useState([])
   const [driveLink, setDriveLink] = useState('')
+  const [accomplishments, setAccomplishments] = useState('')
+  const [blockers, setBlockers] = useState('')
+  const [nextSteps, setNextSteps] = useState('')
+  const [hoursSpent, setHoursSpent] = useState('')
 
   useEffect(() => {
     fetchReports()
@@ -32,6 +37,10 @@ export function WeeklyReports() {
       
       const reportsData = await apiService.getMyReports()
       setReports(reportsData || [])
+      if (userRole === 'student') {
+        const supervisorsData = await apiService.getMySupervisors()
+        setSupervisors(supervisorsData || [])
+      }
     } catch (error) {
       console.error('Error fetching reports:', error)
       toast.error('Failed to fetch reports')
@@ -44,18 +53,18 @@ export function WeeklyReports() {
     e.preventDefault()
     
     if (!recipientId) {
-      toast.error('Please enter a recipient professor ID')
+      toast.error('Please choose the professor to report to')
       return
     }
 
-    if (!driveLink) {
-      toast.error('Please provide a Google Drive link to your report')
+    if (!accomplishments.trim()) {
+      toast.error('Please describe what you accomplished this week')
       return
     }
 
-    // Validate Drive link
-    const formattedLink = formatDriveLink(driveLink.trim())
-    if (!validateDriveLink(formattedLink)) {
+    // The Drive link is optional now, but must be valid if provided
+    const formattedLink = driveLink.trim() ? formatDriveLink(driveLink.trim()) : ''
+    if (formattedLink && !validateDriveLink(formattedLink)) {
       toast.error('Please provide a valid Google Drive link')
       return
     }
@@ -63,7 +72,11 @@ export function WeeklyReports() {
     try {
       const reportData = {
         recipient_id: parseInt(recipientId),
-        drive_link: formattedLink,
+        accomplishments: accomplishments.trim(),
+        blockers: blockers.trim(),
+        next_steps: nextSteps.trim(),
+        hours_spent: hoursSpent ? parseFloat(hoursSpent) : 0,
+        attachments: formattedLink ? [{ name: 'Report document', url: formattedLink }] : [],
       }
 
       await apiService.createReport(reportData)
@@ -71,6 +84,10 @@ export function WeeklyReports() {
       setShowSubmitModal(false)
       setRecipientId('')
       setDriveLink('')
+      setAccomplishments('')
+      setBlockers('')
+      setNextSteps('')
+      setHoursSpent('')
       fetchReports()
     } catch (error) {
       console.error('Error submitting report:', error)
@@ -114,30 +131,13 @@ export function WeeklyReports() {
                       <div className="flex items-center gap-2">
                         <Calendar className="w-5 h-5 icon-muted" />
                         <span className="font-semibold text-heading">
-                          {new Date(report.CreatedAt).toLocaleDateString('en-US', {
-                            year: 'numeric',
-                            month: 'long',
-                            day: 'numeric'
-                          })}
+                          Week {report.week}, {report.year}
                         </span>
                       </div>
-                      <Badge variant="success">Submitted</Badge>
+                      <ReportStatusBadge status={report.status} />
                     </div>
                     <div className="space-y-2 text-sm">
-                      <div>
-                        <span className="font-medium text-heading">Report Document:</span>
-                        <div className="mt-1">
-                          <a
-                            href={report.drive_link}
-                            target="_blank"
-                            rel="noopener noreferrer"
-                            className="text-brand text-sm flex items-center gap-1"
-                          >
-                            View Document
-                            <ExternalLink className="w-3 h-3" />
-                          </a>
-                        </div>
-                      </div>
+                      <ReportSections report={report} />
                     </div>
                   </div>
                 ))}
@@ -154,25 +154,62 @@ export function WeeklyReports() {
           size="lg"
         >
           <form onSubmit={handleSubmitReport} className="space-y-4">
-            <Input
-              label="Professor ID"
-              type="number"
-              placeholder="Enter professor's user ID"
+            <Select
+              label="Professor"
               value={recipientId}
               onChange={(e) => setRecipientId(e.target.value)}
               required
+            >
+              <option value="">Select a supervising professor</option>
+              {supervisors.map((professor) => (
+                <option key={professor.ID} value={professor.ID}>
+                  {professor.first_name} {professor.last_name}
+                </option>
+              ))}
+            </Select>
+            {supervisors.length === 0 && (
+              <p className="text-sm text-body">
+                You can report to professors once they accept one of your applications
+              </p>
+            )}
+            <Textarea
+              label="Accomplishments"
+              rows={4}
+              placeholder="What did you get done this week?"
+              value={accomplishments}
+              onChange={(e) => setAccomplishments(e.target.value)}
+              required
+            />
+            <Textarea
+              label="Blockers"
+              rows={2}
+              placeholder="Anything slowing you down?"
+              value={blockers}
+              onChange={(e) => setBlockers(e.target.value)}
+            />
+            <Textarea
+              label="Next Steps"
+              rows={2}
+              placeholder="What are you planning for next week?"
+              value={nextSteps}
+              onChange={(e) => setNextSteps(e.target.value)}
+            />
+            <Input
+              label="Hours Spent"
+              type="number"
+              min="0"
+              max="168"
+              step="0.5"
+              value={hoursSpent}
+              onChange={(e) => setHoursSpent(e.target.value)}
             />
             <Input
-              label="Report Document (Google Drive Link)"
+              label="Supporting Document (Google Drive Link, optional)"
               type="url"
               placeholder="https://drive.google.com/file/d/..."
               value={driveLink}
               onChange={(e) => setDriveLink(e.target.value)}
-              required
             />
-            <p className="text-sm text-body">
-              Upload your weekly report as a Word document to Google Drive and paste the link here
-            </p>
             <div className="flex gap-3 pt-4">
               <Button type="submit" className="flex-1">
                 Submit Report
@@ -223,11 +260,7 @@ export function WeeklyReports() {
                     <div className="flex items-center gap-2">
                       <Calendar className="w-5 h-5 icon-muted" />
                       <span className="font-semibold text-heading">
-                        {new Date(report.CreatedAt).toLocaleDateString('en-US', {
-                          year: 'numeric',
-                          month: 'long',
-                          day: 'numeric'
-                        })}
+                        Week {report.week}, {report.year}
                       </span>
                     </div>
                     {report.Student && (
@@ -245,20 +278,7 @@ export function WeeklyReports() {
                         </p>
                       </div>
                     )}
-                    <div>
-                      <span className="font-medium text-heading">Report Document:</span>
-                      <div className="mt-1">
-                        <a
-                          href={report.drive_link}
-                          target="_blank"
-                          rel="noopener noreferrer"
-                          className="text-brand text-sm flex items-center gap-1"
-                        >
-                          View Document
-                          <ExternalLink className="w-3 h-3" />
-                        </a>
-                      </div>
-                    </div>
+                    <ReportSections report={report} />
                   </div>
                 </div>
               ))}
@@ -269,3 +289,64 @@ export function WeeklyReports() {
     </div>
   )
 }
+
+const reportStatusBadges = {
+  submitted: ['default', 'Submitted'],
+  approved: ['success', 'Approved'],
+  needs_revision: ['warning', 'Needs Revision'],
+}
+
+function ReportStatusBadge({ status }) {
+  const [variant, label] = reportStatusBadges[status] || reportStatusBadges.submitted
+  return <Badge variant={variant}>{label}</Badge>
+}
+
+function ReportSections({ report }) {
+  const sections = [
+    ['Accomplishments', report.accomplishments],
+    ['Blockers', report.blockers],
+    ['Next Steps', report.next_steps],
+  ].filter(([, text]) => text)
+
+  return (
+    <>
+      {report.opportunity && (
+        <div>
+          <span className="font-medium text-heading">Project:</span>
+          <p className="text-body">{report.opportunity.name}</p>
+        </div>
+      )}
+      {sections.map(([label, text]) => (
+        <div key={label}>
+          <span className="font-medium text-heading">{label}:</span>
+          <p className="text-body whitespace-pre-line">{text}</p>
+        </div>
+      ))}
+      {report.hours_spent > 0 && (
+        <div>
+          <span className="font-medium text-heading">Hours Spent:</span>
+          <span className="text-body ml-1">{report.hours_spent}</span>
+        </div>
+      )}
+      {report.attachments?.length > 0 && (
+        <div>
+          <span className="font-medium text-heading">Attachments:</span>
+          <div className="mt-1 space-y-1">
+            {report.attachments.map((attachment) => (
+              <a
+                key={attachment.ID}
+                href={attachment.url}
+                target="_blank"
+                rel="noopener noreferrer"
+                className="text-brand text-sm flex items-center gap-1"
+              >
+                {attachment.name || 'View Document'}
+                <ExternalLink className="w-3 h-3" />
+              </a>
+            ))}
+          </div>
+        </div>
+      )}
+    </>
+  )
+}
@@ -3,7 +3,7 @@ import { useAuth } from '../../context/AuthContext'
 import { apiService } from '../../services/api'
 import { Card, CardContent, CardHeader, CardTitle, CardDescription } from '../../components/ui/Card'
 import { Button } from '../../components/ui/Button'
-import { Input, Textarea } from '../../components/ui/Input'
+import { Input, Textarea, Select } from '../../components/ui/Input'
 import { Modal } from '../../components/ui/Modal'
 import { 
   Building2, 
@@ -18,6 +18,64 @@ import {
   Link as LinkIcon
 } from 'lucide-react'
 import toast from 'react-hot-toast'
+import { addDays, browserTimezone, formatEventDate, isoToZonedInput, zonedInputToISO } from '../../utils/eventDates'
+
+const timezones = Intl.supportedValuesOf ? Intl.supportedValuesOf('timeZone') : []
+
+const emptyEventForm = () => ({
+  title: '',
+  description: '',
+  date: '',
+  starts_at: '',
+  ends_at: '',
+  all_day: false,
+  timezone: browserTimezone(),
+  location_mode: 'in_person',
+  location: '',
+  link: '',
+  sign_up_link: '',
+})
+
+// Event form values are wall-clock times in the event's timezone; all-day events pick their last day,
+// while the API stores the exclusive end
+function eventToForm(event) {
+  const timezone = event.timezone || browserTimezone()
+  let startsAt = isoToZonedInput(event.starts_at, timezone)
+  let endsAt = isoToZonedInput(event.ends_at, timezone)
+  if (event.all_day) {
+    startsAt = startsAt.slice(0, 10)
+    endsAt = endsAt && addDays(endsAt.slice(0, 10), -1)
+  }
+  return {
+    title: event.title || '',
+    description: event.description || '',
+    date: event.date || '',
+    starts_at: startsAt,
+    ends_at: endsAt,
+    all_day: Boolean(event.all_day),
+    timezone,
+    location_mode: event.location_mode || 'in_person',
+    location: event.location || '',
+    link: event.link || '',
+    sign_up_link: event.sign_up_link || '',
+  }
+}
+
+function formToEvent(form) {
+  const endsAt = form.all_day && form.ends_at ? addDays(form.ends_at, 1) : form.ends_at
+  return {
+    title: form.title,
+    description: form.description,
+    starts_at: zonedInputToISO(form.starts_at, form.timezone),
+    ends_at: zonedInputToISO(endsAt, form.timezone),
+    all_day: form.all_day,
+    timezone: form.timezone,
+    location_mode: form.location_mode,
+    location: form.location,
+    link: form.link,
+    sign_up_link: form.sign_up_link,
+  }
+}
 
 export function OrganizationDashboard() {
   const { currentUser } = useAuth()
@@ -28,13 +86,7 @@ export function OrganizationDashboard() {
   // Modal states
   const [showEventModal, setShowEventModal] = useState(false)
   const [editingEvent, setEditingEvent] = useState(null)
-  const [eventForm, setEventForm] = useState({
-    title: '',
-    description: '',
-    date: '',
-    link: '',
-    sign_up_link: '',
-  })
+  const [eventForm, setEventForm] = useState(emptyEventForm)
   const [submitting, setSubmitting] = useState(false)
 
   useEffect(() => {
@@ -61,38 +113,20 @@ export function OrganizationDashboard() {
 
   const openCreateModal = () => {
     setEditingEvent(null)
-    setEventForm({
-      title: '',
-      description: '',
-      date: '',
-      link: '',
-      sign_up_link: '',
-    })
+    setEventForm(emptyEventForm())
     setShowEventModal(true)
   }
 
   const openEditModal = (event) => {
     setEditingEvent(event)
-    setEventForm({
-      title: event.title || '',
-      description: event.description || '',
-      date: event.date || '',
-      link: event.link || '',
-      sign_up_link: event.sign_up_link || '',
-    })
+    setEventForm(eventToForm(event))
     setShowEventModal(true)
   }
 
   const closeModal = () => {
     setShowEventModal(false)
     setEditingEvent(null)
-    setEventForm({
-      title: '',
-      description: '',
-      date: '',
-      link: '',
-      sign_up_link: '',
-    })
+    setEventForm(emptyEventForm())
   }
 
   const handleFormChange = (e) => {
@@ -100,6 +134,17 @@ export function OrganizationDashboard() {
     setEventForm(prev => ({ ...prev, [name]: value }))
   }
 
+  // Switching between all-day and timed keeps the chosen day
+  const handleAllDayChange = (e) => {
+    const allDay = e.target.checked
+    setEventForm(prev => ({
+      ...prev,
+      all_day: allDay,
+      starts_at: allDay ? prev.starts_at.slice(0, 10) : prev.starts_at && `${prev.starts_at}T09:00`,
+      ends_at: allDay ? prev.ends_at.slice(0, 10) : '',
+    }))
+  }
+
   const handleSubmitEvent = async (e) => {
     e.preventDefault()
     
@@ -108,15 +153,23 @@ export function OrganizationDashboard() {
       return
     }
 
+    let event
+    try {
+      event = formToEvent(eventForm)
+    } catch {
+      toast.error('Unknown timezone')
+      return
+    }
+
     setSubmitting(true)
     try {
       if (editingEvent) {
         // Update existing event
-        await apiService.updateEvent(editingEvent.ID, eventForm)
+        await apiService.updateEvent(editingEvent.ID, event)
         toast.success('Event updated successfully')
       } else {
         // Create new event
-        await apiService.createEvent(eventForm)
+        await apiService.createEvent(event)
         toast.success('Event created successfully')
       }
       closeModal()
@@ -249,10 +302,10 @@ export function OrganizationDashboard() {
                   <div className="flex flex-col sm:flex-row sm:items-start sm:justify-between gap-3 sm:gap-4">
                     <div className="flex-1 min-w-0">
                       <h3 className="font-semibold text-base sm:text-lg text-heading">{event.title}</h3>
-                      {event.date && (
+                      {formatEventDate(event) && (
                         <p className="text-xs sm:text-sm text-brand mt-1 flex items-center gap-1">
                           <Calendar className="w-3 h-3 sm:w-4 sm:h-4 flex-shrink-0" />
-                          <span className="truncate">{event.date}</span>
+                          <span className="truncate">{formatEventDate(event)}</span>
                         </p>
                       )}
                       {event.description && (
@@ -336,13 +389,74 @@ export function OrganizationDashboard() {
             rows={4}
           />
           
+          <div className="grid grid-cols-1 sm:grid-cols-2 gap-4">
+            <Input
+              label={eventForm.all_day ? 'First day' : 'Starts'}
+              type={eventForm.all_day ? 'date' : 'datetime-local'}
+              name="starts_at"
+              value={eventForm.starts_at}
+              onChange={handleFormChange}
+              helperText={editingEvent && !editingEvent.starts_at && eventForm.date ? `Previously: ${eventForm.date}` : undefined}
+              required
+            />
+            <Input
+              label={eventForm.all_day ? 'Last day (optional)' : 'Ends (optional)'}
+              type={eventForm.all_day ? 'date' : 'datetime-local'}
+              name="ends_at"
+              value={eventForm.ends_at}
+              min={eventForm.starts_at}
+              onChange={handleFormChange}
+            />
+          </div>
+
+          <div className="flex items-center gap-2">
+            <input
+              type="checkbox"
+              id="all_day"
+              checked={eventForm.all_day}
+              onChange={handleAllDayChange}
+              className="w-4 h-4 text-brand border-default rounded focus:ring-brand"
+            />
+            <label htmlFor="all_day" className="text-sm text-body cursor-pointer">
+              All-day event
+            </label>
+          </div>
+
           <Input
-            label="Date / Duration"
-            name="date"
-            value={eventForm.date}
+            label="Timezone"
+            name="timezone"
+            value={eventForm.timezone}
             onChange={handleFormChange}
-            placeholder="e.g., March 15, 2026 or March 15-17, 2026"
+            list="event-timezones"
+            placeholder="e.g., Africa/Cairo"
+            required
           />
+          <datalist id="event-timezones">
+            {timezones.map((zone) => (
+              <option key={zone} value={zone} />
+            ))}
+          </datalist>
+
+          <div className="grid grid-cols-1 sm:grid-cols-3 gap-4">
+            <Select
+              label="Format"
+              name="location_mode"
+              value={eventForm.location_mode}
+              onChange={handleFormChange}
+            >
+              <option value="in_person">In person</option>
+              <option value="online">Online</option>
+            </Select>
+            <div className="sm:col-span-2">
+              <Input
+                label={eventForm.location_mode === 'online' ? 'Meeting link (optional)' : 'Address (optional)'}
+                name="location"
+                value={eventForm.location}
+                onChange={handleFormChange}
+                placeholder={eventForm.location_mode === 'online' ? 'https://meet.google.com/...' : 'e.g., Hall B, main campus'}
+              />
+            </div>
+          </div>
           
           <Input
             label="Learn More Link (optional)"
@@ -279,14 +279,57 @@ class ApiService {
     return this.delete(`/api/reports/${reportId}`)
   }
 
+  /**
+   * Get the professors I can send reports to (student only)
+   */
+  async getMySupervisors() {
+    return this.get('/api/reports/supervisors')
+  }
+
+  /**
+   * Get the students I supervise (professor only)
+   */
+  async getSupervisedStudents() {
+    return this.get('/api/reports/students')
+  }
+
+  /**
+   * Approve a report or send it back (professor only)
+   * @param {string} status - 'approved' or 'needs_revision'
+   */
+  async reviewReport(reportId, status, comment = '') {
+    return this.put(`/api/reports/${reportId}/review`, { status, comment })
+  }
+
+  /**
+   * Get the feedback thread of a report
+   */
+  async getReportComments(reportId) {
+    return this.get(`/api/reports/${reportId}/comments`)
+  }
+
+  /**
+   * Add a comment to a report's feedback thread
+   */
+  async addReportComment(reportId, body) {
+    return this.post(`/api/reports/${reportId}/comments`, { body })
+  }
+
   // ==================== NOTIFICATIONS ENDPOINTS ====================
 
   /**
-   * Get my notifications
+   * Get a page of my notifications, newest first
    * @param {boolean} unreadOnly - If true, only returns unread notifications
-   */
-  async getMyNotifications(unreadOnly = false) {
-    const query = unreadOnly ? '?unread_only=true' : ''
+   * @param {Object} options - { type, since, until, limit, before } where before is next_before from the previous page
+   * @returns {Promise<{items: Object[], next_before?: string}>} next_before is missing on the last page
+   */
+  async getMyNotifications(unreadOnly = false, options = {}) {
+    const params = new URLSearchParams()
+    if (unreadOnly) params.set('unread_only', 'true')
+    for (const [key, value] of Object.entries(options)) {
+      if (value !== undefined && value !== null && value !== '') params.set(key, value)
+    }
+    const query = params.toString() ? `?${params}` : ''
     return this.get(`/api/notifications/me${query}`)
   }
 
@@ -297,6 +340,52 @@ class ApiService {
     return this.get('/api/notifications/me/count')
   }
 
+  /**
+   * Subscribe to my notifications as they arrive (Server-Sent Events).
+   * Each connection uses a single-use stream ticket, so instead of letting EventSource retry with a
+   * spent ticket we reconnect with a fresh one and resume after the last event received.
+   * @param {function} onNotification - called with each new notification
+   * @param {function} onReset - called when more were missed than can be replayed; reload the list
+   * @returns {function} call to close the stream
+   */
+  streamNotifications(onNotification, onReset = () => {}) {
+    let source = null
+    let lastEventId = ''
+    let retryTimer = null
+    let closed = false
+
+    const connect = async () => {
+      try {
+        const { ticket } = await this.post('/api/notifications/stream-ticket')
+        if (closed) return
+        const params = new URLSearchParams({ ticket })
+        if (lastEventId) params.set('last_event_id', lastEventId)
+        source = new EventSource(`${this.baseURL}/api/notifications/stream?${params}`)
+        source.addEventListener('notification', (event) => {
+          lastEventId = event.lastEventId
+          onNotification(JSON.parse(event.data))
+        })
+        source.addEventListener('reset', (event) => {
+          lastEventId = event.lastEventId
+          onReset()
+        })
+        source.onerror = () => {
+          source.close()
+          if (!closed) retryTimer = setTimeout(connect, 5000)
+        }
+      } catch {
+        if (!closed) retryTimer = setTimeout(connect, 5000)
+      }
+    }
+    connect()
+
+    return () => {
+      closed = true
+      clearTimeout(retryTimer)
+      if (source) source.close()
+    }
+  }
+
   /**
    * Mark notification as read
    */
@@ -318,6 +407,65 @@ class ApiService {
     return this.delete(`/api/notifications/${notificationId}`)
   }
 
+  /**
+   * Delete several notifications
+   * @param {number[]} notificationIds - IDs to delete
+   */
+  async deleteNotifications(notificationIds) {
+    return this.request('/api/notifications', { method: 'DELETE', body: JSON.stringify({ ids: notificationIds }) })
+  }
+
+  /**
+   * Delete all my read notifications
+   */
+  async deleteReadNotifications() {
+    return this.request('/api/notifications', { method: 'DELETE', body: JSON.stringify({ read: true }) })
+  }
+
+  /**
+   * Get how each notification type reaches me (in_app, email, digest or none)
+   */
+  async getNotificationPreferences() {
+    return this.get('/api/notifications/preferences')
+  }
+
+  /**
+   * Change notification channels
+   * @param {Object} preferences - Map of event type to channel, e.g. { report_review: 'digest' }
+   */
+  async updateNotificationPreferences(preferences) {
+    return this.put('/api/notifications/preferences', preferences)
+  }
+
+  /**
+   * Get when my digest emails are sent
+   */
+  async getDigestSettings() {
+    return this.get('/api/notifications/digest')
+  }
+
+  /**
+   * Change my digest schedule
+   * @param {Object} settings - { frequency: 'daily'|'weekly', weekday, hour, minute, timezone }
+   */
+  async updateDigestSettings(settings) {
+    return this.put('/api/notifications/digest', settings)
+  }
+
+  /**
+   * Check whether emails to my address are still delivered (stopped after bounces or spam complaints)
+   */
+  async getEmailStatus() {
+    return this.get('/api/email-status')
+  }
+
+  /**
+   * Resume emails to my address after fixing it
+   */
+  async retryEmailDelivery() {
+    return this.post('/api/email-status/retry')
+  }
+
   // ==================== COINS ENDPOINTS ====================
 
   /**
@@ -328,19 +476,71 @@ class ApiService {
   }
 
   /**
-   * Increment coins (student only)
+   * Get my coin transaction history (student only)
+   */
+  async getMyCoinTransactions() {
+    return this.get('/api/coins/me/transactions')
+  }
+
+  /**
+   * Get the public leaderboard (window: all_time | semester | month, by: coins | applications | reports)
+   */
+  async getLeaderboard(window = 'all_time', by = 'coins', tag = '') {
+    const params = new URLSearchParams({ window, by })
+    if (tag) params.set('tag', tag)
+    return this.get(`/public/leaderboard?${params}`)
+  }
+
+  /**
+   * Hide or show myself on the public leaderboard (student only)
    */
-  async incrementCoins(amount) {
-    return this.post('/api/coins/increment', { amount })
+  async setLeaderboardOptOut(optOut) {
+    return this.put('/api/students/me/leaderboard', { opt_out: optOut })
+  }
+
+  // ==================== REWARDS ENDPOINTS ====================
+
+  /**
+   * Get the catalog of redeemable rewards
+   */
+  async getRewards() {
+    return this.get('/api/rewards')
+  }
+
+  /**
+   * Redeem a reward for a voucher (student only)
+   */
+  async redeemReward(rewardId) {
+    return this.post(`/api/rewards/${rewardId}/redeem`)
+  }
+
+  /**
+   * Get my vouchers (student only)
+   */
+  async getMyRedemptions() {
+    return this.get('/api/rewards/redemptions/me')
+  }
+
+  /**
+   * Verify a voucher code (issuing organization only)
+   */
+  async verifyVoucher(code) {
+    return this.get(`/api/rewards/vouchers/${encodeURIComponent(code)}`)
   }
 
   // ==================== EVENTS ENDPOINTS ====================
 
   /**
-   * Get all events (public)
+   * Get events (public)
+   * @param {Object} filters - { when: 'upcoming'|'past', from, until } with dates as YYYY-MM-DD or ISO strings
    */
-  async getAllEvents() {
-    return this.get('/public/events')
+  async getAllEvents(filters = {}) {
+    const params = new URLSearchParams()
+    for (const [key, value] of Object.entries(filters)) {
+      if (value !== undefined && value !== null && value !== '') params.set(key, value)
+    }
+    const query = params.toString() ? `?${params}` : ''
+    return this.get(`/public/events${query}`)
   }
 
   /**
@@ -393,6 +593,31 @@ class ApiService {
   async getEventsByOrganizationId(id) {
     return this.get(`/public/organizations/${id}/events`)
   }
+
+  /**
+   * Get published opportunities by organization ID (public)
+   */
+  async getOpportunitiesByOrganizationId(id) {
+    return this.get(`/public/organizations/${id}/opportunities`)
+  }
+
+  // ==================== UNSUBSCRIBE ENDPOINTS ====================
+
+  /**
+   * What an email's unsubscribe link stops (public, changes nothing)
+   * @param {string} token - token from the link
+   */
+  async getUnsubscribe(token) {
+    return this.get(`/public/unsubscribe?token=${encodeURIComponent(token)}`)
+  }
+
+  /**
+   * Stop the emails an unsubscribe link is for (public)
+   * @param {string} token - token from the link
+   */
+  async unsubscribe(token) {
+    return this.post(`/public/unsubscribe?token=${encodeURIComponent(token)}`)
+  }
 }
 
 export const apiService = new ApiService()
@@ -0,0 +1,93 @@
+/**
+ * Helpers for event schedules. The API stores instants (starts_at, ends_at) plus the IANA timezone
+ * the event is held in; forms edit wall-clock times in that timezone. ends_at is exclusive, so an
+ * all-day event ends at midnight after its last day.
+ */
+
+export const browserTimezone = () => Intl.DateTimeFormat().resolvedOptions().timeZone || 'UTC'
+
+// Wall-clock fields of an instant in a timezone
+function zonedParts(date, timeZone) {
+  const parts = new Intl.DateTimeFormat('en-US', {
+    timeZone,
+    hourCycle: 'h23',
+    year: 'numeric',
+    month: '2-digit',
+    day: '2-digit',
+    hour: '2-digit',
+    minute: '2-digit',
+    second: '2-digit',
+  }).formatToParts(date)
+  return Object.fromEntries(parts.map((p) => [p.type, p.value]))
+}
+
+// How far a timezone is ahead of UTC at an instant, in milliseconds
+function zoneOffset(date, timeZone) {
+  const p = zonedParts(date, timeZone)
+  const asUTC = Date.UTC(p.year, p.month - 1, p.day, p.hour, p.minute, p.second)
+  return asUTC - Math.floor(date.getTime() / 1000) * 1000
+}
+
+/**
+ * Convert a date ("2026-03-15") or datetime-local ("2026-03-15T14:00") value in timeZone to an ISO string
+ */
+export function zonedInputToISO(value, timeZone) {
+  if (!value) return undefined
+  const [date, time = '00:00'] = value.split('T')
+  const [year, month, day] = date.split('-').map(Number)
+  const [hour, minute] = time.split(':').map(Number)
+  const wall = Date.UTC(year, month - 1, day, hour, minute)
+  // the offset depends on the instant, so correct once for DST changes
+  let instant = wall - zoneOffset(new Date(wall), timeZone)
+  instant = wall - zoneOffset(new Date(instant), timeZone)
+  return new Date(instant).toISOString()
+}
+
+/**
+ * Convert an ISO instant to a datetime-local value ("2026-03-15T14:00") in timeZone
+ */
+export function isoToZonedInput(iso, timeZone) {
+  if (!iso) return ''
+  const p = zonedParts(new Date(iso), timeZone)
+  return `${p.year}-${p.month}-${p.day}T${p.hour}:${p.minute}`
+}
+
+/**
+ * Add days to a date value ("2026-03-15")
+ */
+export function addDays(date, days) {
+  const d = new Date(`${date}T00:00:00Z`)
+  d.setUTCDate(d.getUTCDate() + days)
+  return d.toISOString().slice(0, 10)
+}
+
+/**
+ * Human-readable schedule of an event in its own timezone, falling back to the free-form date of
+ * old events that could not be parsed
+ */
+export function formatEventDate(event) {
+  if (!event?.starts_at) return event?.date || ''
+  const timeZone = event.timezone || 'UTC'
+  const start = new Date(event.starts_at)
+
+  if (event.all_day) {
+    const format = new Intl.DateTimeFormat(undefined, { timeZone, dateStyle: 'medium' })
+    // the last day is the one before the exclusive end
+    const last = event.ends_at ? new Date(new Date(event.ends_at).getTime() - 1) : start
+    return last > start && format.formatRange ? format.formatRange(start, last) : format.format(start)
+  }
+
+  const format = new Intl.DateTimeFormat(undefined, { timeZone, dateStyle: 'medium', timeStyle: 'short' })
+  const when = event.ends_at && format.formatRange
+    ? format.formatRange(start, new Date(event.ends_at))
+    : format.format(start)
+  return timeZone === browserTimezone() ? when : `${when} (${timeZone})`
+}
+
+/**
+ * Whether an event has already ended
+ */
+export function isPastEvent(event) {
+  const end = event?.ends_at || event?.starts_at
+  return Boolean(end) && new Date(end) <= new Date()
+}